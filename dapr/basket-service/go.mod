@@ -1,8 +1,6 @@
 module basket-service
 
-go 1.21.8
-
-toolchain go1.22.0
+go 1.22.0
 
 require (
 	github.com/dapr/go-sdk v1.10.1
@@ -10,6 +8,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/rabbitmq/amqp091-go v1.9.0
 	github.com/redis/go-redis/v9 v9.5.1
+	money-go v0.0.0
 )
 
 require (
@@ -37,3 +36,5 @@ require (
 	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace money-go => ../../money/money-go
@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"money-go"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -18,7 +22,7 @@ type Basket struct {
 type BasketItem struct {
 	Id        uuid.UUID `json:"id"`
 	ProductId uuid.UUID `json:"catalogId"`
-	Price     float64   `json:"price"`
+	Price     money.Money `json:"price"`
 	Quantity  uint      `json:"quantity"`
 }
 
@@ -31,20 +35,29 @@ type CreateBasketItemRequest struct {
 	Quantity  uint   `json:"quantity"`
 }
 
+// ProductResponse is a product as product-service sends it. Price is kept as
+// the literal JSON number so that it converts to money.Money exactly.
 type ProductResponse struct {
-	Id          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Id          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       json.Number `json:"price"`
 }
 
+// currency is the currency of every basket; product-service prices carry none
+// of their own.
+const currency = "GBP"
+
 func main() {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
 	app := fiber.New()
 	ctx := context.Background()
 
 	client, err := dapr.NewClient()
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("Failed to create Dapr client", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	// basketSchema := schema.Object(map[string]schema.ISchema{
@@ -59,8 +72,7 @@ func main() {
 
 	data, err := client.InvokeMethod(ctx, "product-service", "/api/products/72119506-89ef-4c0c-ace7-6cbd984bfc50", "GET")
 		
-	log.Printf("%v", err)
-	log.Printf("%v", data)
+	slog.Info("Invoked product-service", slog.Any("error", err), slog.String("data", string(data)))
 
 	// app.Get("/api/basket/:id", func(c *fiber.Ctx) error {
 	// 	id, err := uuid.Parse(c.Params("id"))
@@ -124,10 +136,15 @@ func main() {
 	// 			return err
 	// 		}
 
+	// 		price, err := money.Parse(product.Price.String(), currency)
+	// 		if err != nil {
+	// 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Invalid product"})
+	// 		}
+
 	// 		basketItem := BasketItem{
 	// 			Id:        uuid.New(),
 	// 			ProductId: uuid.MustParse(item.ProductId),
-	// 			Price:     product.Price,
+	// 			Price:     price,
 	// 			Quantity:  item.Quantity,
 	// 		}
 
@@ -183,5 +200,8 @@ func main() {
 	// 	return c.SendStatus(fiber.StatusNoContent)
 	// })
 
-	app.Listen(":8080")
+	if err := app.Listen(":8080"); err != nil {
+		slog.Error("Server failed", slog.Any("error", err))
+		os.Exit(1)
+	}
 }
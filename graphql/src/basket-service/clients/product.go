@@ -17,8 +17,18 @@ type Product struct {
 	Id *uuid.UUID `json:"id"`
 }
 
+// ProductClient looks products up through the GraphQL endpoint at url.
+type ProductClient struct {
+	url  string
+	http *http.Client
+}
+
+func NewProductClient(url string, httpClient *http.Client) *ProductClient {
+	return &ProductClient{url: url, http: httpClient}
+}
+
 // TODO: Better error handling
-func GetProduct(ctx context.Context, id uuid.UUID) (bool, error) {
+func (c *ProductClient) GetProduct(ctx context.Context, id uuid.UUID) (bool, error) {
 	query := `
 	query Product($productId: UUID!) {
 		product(id: $productId) {
@@ -41,13 +51,13 @@ func GetProduct(ctx context.Context, id uuid.UUID) (bool, error) {
 		return false, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "http://localhost:4000/graphql", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(body))
 	if err != nil {
 		return false, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return false, err
 	}
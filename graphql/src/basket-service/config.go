@@ -0,0 +1,27 @@
+package main
+
+import "service-kit/config"
+
+// Config is everything basket-service can be configured with. See config.Load
+// for where each value comes from.
+type Config struct {
+	Port     int             `yaml:"port" env:"PORT" default:"8080"`
+	Store    string          `yaml:"store" env:"BASKET_STORE" default:"redis" oneof:"redis postgres memory"`
+	Redis    config.Redis    `yaml:"redis"`
+	Postgres config.Postgres `yaml:"postgres"`
+
+	// ProductsURL is the GraphQL endpoint products are looked up at.
+	ProductsURL string `yaml:"productsUrl" env:"PRODUCTS_GRAPHQL_URL" default:"http://localhost:4000/graphql"`
+}
+
+// Validate checks that whatever the chosen store needs is set.
+func (c Config) Validate() error {
+	switch c.Store {
+	case "redis":
+		return c.Redis.Require("redis")
+	case "postgres":
+		return c.Postgres.Require("postgres")
+	}
+
+	return nil
+}
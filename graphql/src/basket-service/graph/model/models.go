@@ -1,10 +1,28 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// Currency is the currency of every basket. product-service prices carry no
+// currency of their own, so they are all taken to be in it.
+const Currency = "GBP"
+
+// Basket is bound in the schema rather than generated so that the owner can be
+// persisted without being exposed through the graph.
+type Basket struct {
+	ID      uuid.UUID     `json:"id"`
+	OwnerID string        `json:"ownerId,omitempty"`
+	Items   []*BasketItem `json:"items"`
+}
+
+func (Basket) IsResponse() {}
 
 type BasketItem struct {
-	ID        uuid.UUID `json:"id"`
-	ProductID uuid.UUID `json:"productId"`
-	Price     float64   `json:"price"`
-	Quantity  uint      `json:"quantity"`
+	ID        uuid.UUID   `json:"id"`
+	ProductID uuid.UUID   `json:"productId"`
+	Price     money.Money `json:"price"`
+	Quantity  uint        `json:"quantity"`
 }
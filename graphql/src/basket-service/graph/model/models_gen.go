@@ -10,13 +10,6 @@ type Response interface {
 	IsResponse()
 }
 
-type Basket struct {
-	ID    uuid.UUID     `json:"id"`
-	Items []*BasketItem `json:"items"`
-}
-
-func (Basket) IsResponse() {}
-
 type CreateBasketItemRequest struct {
 	ProductID uuid.UUID `json:"productId"`
 	Quantity  uint      `json:"quantity"`
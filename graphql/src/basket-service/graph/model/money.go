@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"money-go"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalMoney writes the Money scalar in the same shape as its JSON, so an
+// amount looks the same over GraphQL as it does over REST.
+func MarshalMoney(m money.Money) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			panic(err)
+		}
+
+		w.Write(data)
+	})
+}
+
+// UnmarshalMoney reads a Money scalar input, an object with amount and
+// currency strings.
+func UnmarshalMoney(v any) (money.Money, error) {
+	object, ok := v.(map[string]any)
+	if !ok {
+		return money.Money{}, fmt.Errorf("money: %T is not a Money object", v)
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	var m money.Money
+	err = m.UnmarshalJSON(data)
+	return m, err
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"money-go"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MarshalMoney(t *testing.T) {
+	var buf bytes.Buffer
+	MarshalMoney(money.MustParse("25360.05", "GBP")).MarshalGQL(&buf)
+	require.JSONEq(t, `{"amount":"25360.05","currency":"GBP"}`, buf.String())
+
+	m, err := UnmarshalMoney(map[string]any{"amount": "0.33", "currency": "GBP"})
+	require.NoError(t, err)
+	require.Equal(t, money.New(33, "GBP"), m)
+
+	_, err = UnmarshalMoney("0.33")
+	require.Error(t, err)
+}
@@ -1,11 +1,15 @@
 package graph
 
-import "github.com/redis/go-redis/v9"
+import (
+	"basket-service/clients"
+	"basket-service/store"
+)
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	Redis *redis.Client
+	Baskets  store.BasketStore
+	Products *clients.ProductClient
 }
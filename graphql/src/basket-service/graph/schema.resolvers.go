@@ -7,8 +7,9 @@ package graph
 import (
 	"basket-service/graph/model"
 	"context"
-	"encoding/json"
-	"time"
+	"log/slog"
+	"money-go"
+	"service-kit/logging"
 
 	"github.com/google/uuid"
 	"github.com/vektah/gqlparser/v2/gqlerror"
@@ -33,17 +34,14 @@ func (r *mutationResolver) CreateBasket(ctx context.Context, input model.CreateB
 		basket.Items = append(basket.Items, &model.BasketItem{
 			ID:        uuid.New(),
 			ProductID: item.ProductID,
-			Price:     10,
+			Price:     money.New(1000, model.Currency),
 			Quantity:  item.Quantity,
 		})
 	}
 
-	bytes, err := json.Marshal(basket)
-	if err != nil {
-		return nil, gqlerror.Errorf("Unable to save basket: %v", err)
-	}
-
-	if err := r.Redis.Set(ctx, basket.ID.String(), string(bytes), 24*time.Hour).Err(); err != nil {
+	if err := r.Baskets.Create(ctx, &basket); err != nil {
+		ctx = logging.WithAttrs(ctx, slog.String(logging.BasketIDKey, basket.ID.String()))
+		slog.ErrorContext(ctx, "Unable to save basket", slog.Any("error", err))
 		return nil, gqlerror.Errorf("Unable to save basket: %v", err)
 	}
 
@@ -54,17 +52,14 @@ func (r *mutationResolver) CreateBasket(ctx context.Context, input model.CreateB
 
 // Basket is the resolver for the basket field.
 func (r *queryResolver) Basket(ctx context.Context, id uuid.UUID) (*model.Basket, error) {
-	value, err := r.Redis.Get(ctx, id.String()).Result()
+	basket, err := r.Baskets.Get(ctx, id)
 	if err != nil {
+		ctx = logging.WithAttrs(ctx, slog.String(logging.BasketIDKey, id.String()))
+		slog.WarnContext(ctx, "Record not found", slog.Any("error", err))
 		return nil, gqlerror.Errorf("Record not found: %v", err)
 	}
 
-	var basket model.Basket
-	if err := json.Unmarshal([]byte(value), &basket); err != nil {
-		return nil, gqlerror.Errorf("Record not found: %v", err)
-	}
-
-	return &basket, nil
+	return basket, nil
 }
 
 // BasketItem returns BasketItemResolver implementation.
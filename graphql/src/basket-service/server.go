@@ -1,53 +1,116 @@
 package main
 
 import (
+	"basket-service/clients"
 	"basket-service/graph"
+	"basket-service/store"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"runtime/debug"
+	"service-kit/config"
+	"service-kit/health"
+	"service-kit/lifecycle"
+	"service-kit/logging"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
-const defaultPort = "8080"
+const (
+	basketTTL = 24 * time.Hour
+
+	// shutdownTimeout is how long in-flight requests have to finish, and
+	// connections to close, once the service is asked to stop.
+	shutdownTimeout = 20 * time.Second
+)
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
-	}
+	slog.SetDefault(logging.New(os.Stdout))
 
-	redis := connectToRedis()
+	var cfg Config
+	config.LoadOrExit(&cfg)
+	lc := lifecycle.New(shutdownTimeout)
+	ready := health.NewChecker(2 * time.Second)
 
 	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
-		Redis: redis,
+		Baskets:  connectToStore(cfg, lc, ready),
+		Products: clients.NewProductClient(cfg.ProductsURL, http.DefaultClient),
 	}}))
+	srv.SetRecoverFunc(recoverResolver)
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/graphql"))
-	http.Handle("/graphql", srv)
+	http.Handle("/healthz", health.Live())
+	http.Handle("/readyz", ready)
+	http.Handle("/graphql", logging.Middleware(srv))
 
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	slog.Info(fmt.Sprintf("connect to http://localhost:%d/ for GraphQL playground", cfg.Port))
+	server := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port)}
+	if err := lc.Run(server.ListenAndServe, server.Shutdown); err != nil {
+		logging.Fatal("Shut down with errors", err)
+	}
 }
 
-func connectToRedis() *redis.Client {
-	server := os.Getenv("REDIS_HOST")
-	port, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
-	if err != nil {
-		log.Panicf("Could parse REDIS_PORT to int: %v", err)
-	}
+// recoverResolver logs a resolver that panicked against its request, and
+// reports it to the caller as an internal error.
+func recoverResolver(ctx context.Context, err any) error {
+	slog.ErrorContext(ctx, "Resolver panicked", slog.Any("error", err), slog.String("stack", string(debug.Stack())))
 
-	pass := os.Getenv("REDIS_PASSWORD")
+	return gqlerror.Errorf("internal system error")
+}
 
+// connectToStore returns the BasketStore for the configured store. The
+// connection is checked for readiness, and closed at shutdown.
+func connectToStore(cfg Config, lc *lifecycle.Manager, ready *health.Checker) store.BasketStore {
+	switch cfg.Store {
+	case "redis":
+		rdb := connectToRedis(cfg.Redis)
+		lc.OnShutdown("Redis", lifecycle.Close(rdb.Close))
+		ready.Add("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+		return store.NewRedisStore(rdb, basketTTL)
+	case "postgres":
+		pool := connectToPostgres(cfg.Postgres)
+		lc.OnShutdown("Postgres", func(context.Context) error {
+			pool.Close()
+			return nil
+		})
+		ready.Add("postgres", pool.Ping)
+		s, err := store.NewPostgresStore(context.Background(), pool, basketTTL)
+		if err != nil {
+			logging.Fatal("Failed to create baskets table", err)
+		}
+		return s
+	case "memory":
+		return store.NewMemoryStore(basketTTL)
+	default:
+		// config.Load has already checked the store is one of the above
+		panic("unknown store " + cfg.Store)
+	}
+}
+
+func connectToRedis(cfg config.Redis) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", server, port),
-		Password: pass,
+		Addr:     cfg.Addr(),
+		Password: cfg.Password,
 		DB:       0,
 	})
 
 	return rdb
 }
+
+func connectToPostgres(cfg config.Postgres) *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), cfg.URL())
+	if err != nil {
+		logging.Fatal("Failed to connect to Postgres", err)
+	}
+
+	return pool
+}
@@ -0,0 +1,147 @@
+// Package auth issues the tokens that identify a signed in user to the other
+// services, and checks them on the way back in.
+//
+// Tokens are JWTs signed with HS256 using a secret shared with every service
+// that accepts them. The subject is the user's id. A token is only issued once
+// the user has proved who they are with their password, which is kept as a
+// bcrypt hash.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user-service/graph/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Issuer is the iss claim of every token.
+const Issuer = "user-service"
+
+// Claims are what a token says about its user.
+type Claims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func New(secret []byte, ttl time.Duration) *Tokens {
+	return &Tokens{secret: secret, ttl: ttl}
+}
+
+// Issue returns a signed token for user, valid for the ttl Tokens was created
+// with.
+func (t *Tokens) Issue(user model.User) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Email: user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+		},
+	})
+
+	return token.SignedString(t.secret)
+}
+
+// Verify checks a token's signature, issuer and expiry and returns its claims.
+func (t *Tokens) Verify(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return t.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+type contextKey struct{}
+
+// Middleware puts the claims of a valid bearer token on the request context.
+// Requests without one, or with one that has expired, carry on anonymously so
+// that a user can still sign in again; resolvers that need a user look for one
+// with FromContext.
+func (t *Tokens) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			if claims, err := t.Verify(token); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), contextKey{}, claims))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+var (
+	// ErrUnauthenticated is returned by resolvers that need a signed in user.
+	ErrUnauthenticated = errors.New("not signed in")
+
+	// ErrForbidden is returned by resolvers that need an admin.
+	ErrForbidden = errors.New("not allowed")
+
+	// ErrInvalidCredentials is returned when signing in with an unknown email
+	// or the wrong password. It does not say which, so that it cannot be used
+	// to find out who has an account.
+	ErrInvalidCredentials = errors.New("email or password is incorrect")
+
+	// ErrPasswordTooShort is returned by HashPassword for a password shorter
+	// than MinPasswordLength.
+	ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+)
+
+// MinPasswordLength is the fewest characters a password may have.
+const MinPasswordLength = 8
+
+// HashPassword returns the bcrypt hash of password to keep for a user.
+func HashPassword(password string) ([]byte, error) {
+	if len([]rune(password)) < MinPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// noUser is compared against when signing in with an unknown email, so that
+// takes as long as a wrong password does.
+var noUser, _ = bcrypt.GenerateFromPassword([]byte("no such user"), bcrypt.DefaultCost)
+
+// CheckPassword returns ErrInvalidCredentials unless password is the one
+// user's hash was made from. A nil user is an unknown email.
+func CheckPassword(user *model.User, password string) error {
+	hash := noUser
+	if user != nil {
+		hash = user.PasswordHash
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || user == nil {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// FromContext returns the claims Middleware found on the request, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"testing"
+	"user-service/graph/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Password(t *testing.T) {
+	_, err := HashPassword("short")
+	require.ErrorIs(t, err, ErrPasswordTooShort)
+
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	require.NotContains(t, string(hash), "correct horse")
+
+	user := &model.User{Email: "alice@example.com", PasswordHash: hash}
+	require.NoError(t, CheckPassword(user, "correct horse"))
+	require.ErrorIs(t, CheckPassword(user, "battery staple"), ErrInvalidCredentials)
+	require.ErrorIs(t, CheckPassword(&model.User{}, "correct horse"), ErrInvalidCredentials, "a user without a password cannot sign in")
+	require.ErrorIs(t, CheckPassword(nil, "no such user"), ErrInvalidCredentials, "an unknown email is reported like a wrong password")
+}
@@ -0,0 +1,22 @@
+package main
+
+import "service-kit/config"
+
+// Config is everything user-service can be configured with. See config.Load
+// for where each value comes from.
+type Config struct {
+	Port     int             `yaml:"port" env:"PORT" default:"8080"`
+	Postgres config.Postgres `yaml:"postgres"`
+
+	// AuthSecret signs the tokens handed out at sign in. basket-service has
+	// to be given the same secret to verify them.
+	AuthSecret string `yaml:"authSecret" env:"AUTH_SECRET" required:"true" secret:"true"`
+
+	// Admins are the emails of the users allowed to list every user.
+	Admins []string `yaml:"admins" env:"ADMINS"`
+}
+
+// Validate checks that the database is configured.
+func (c Config) Validate() error {
+	return c.Postgres.Require("postgres")
+}
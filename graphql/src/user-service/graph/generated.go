@@ -71,10 +71,12 @@ type ComplexityRoot struct {
 
 	SigninPayload struct {
 		Response func(childComplexity int) int
+		Token    func(childComplexity int) int
 	}
 
 	SignupPayload struct {
 		Response func(childComplexity int) int
+		Token    func(childComplexity int) int
 	}
 
 	User struct {
@@ -190,6 +192,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.SigninPayload.Response(childComplexity), true
 
+	case "SigninPayload.token":
+		if e.complexity.SigninPayload.Token == nil {
+			break
+		}
+
+		return e.complexity.SigninPayload.Token(childComplexity), true
+
 	case "SignupPayload.response":
 		if e.complexity.SignupPayload.Response == nil {
 			break
@@ -197,6 +206,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.SignupPayload.Response(childComplexity), true
 
+	case "SignupPayload.token":
+		if e.complexity.SignupPayload.Token == nil {
+			break
+		}
+
+		return e.complexity.SignupPayload.Token(childComplexity), true
+
 	case "User.email":
 		if e.complexity.User.Email == nil {
 			break
@@ -677,6 +693,8 @@ func (ec *executionContext) fieldContext_Mutation_signup(ctx context.Context, fi
 			switch field.Name {
 			case "response":
 				return ec.fieldContext_SignupPayload_response(ctx, field)
+			case "token":
+				return ec.fieldContext_SignupPayload_token(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type SignupPayload", field.Name)
 		},
@@ -736,6 +754,8 @@ func (ec *executionContext) fieldContext_Mutation_signin(ctx context.Context, fi
 			switch field.Name {
 			case "response":
 				return ec.fieldContext_SigninPayload_response(ctx, field)
+			case "token":
+				return ec.fieldContext_SigninPayload_token(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type SigninPayload", field.Name)
 		},
@@ -1080,6 +1100,47 @@ func (ec *executionContext) fieldContext_SigninPayload_response(ctx context.Cont
 	return fc, nil
 }
 
+func (ec *executionContext) _SigninPayload_token(ctx context.Context, field graphql.CollectedField, obj *model.SigninPayload) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SigninPayload_token(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Token, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SigninPayload_token(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SigninPayload",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _SignupPayload_response(ctx context.Context, field graphql.CollectedField, obj *model.SignupPayload) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_SignupPayload_response(ctx, field)
 	if err != nil {
@@ -1121,6 +1182,47 @@ func (ec *executionContext) fieldContext_SignupPayload_response(ctx context.Cont
 	return fc, nil
 }
 
+func (ec *executionContext) _SignupPayload_token(ctx context.Context, field graphql.CollectedField, obj *model.SignupPayload) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_SignupPayload_token(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Token, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_SignupPayload_token(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "SignupPayload",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _User_id(ctx context.Context, field graphql.CollectedField, obj *model.User) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_User_id(ctx, field)
 	if err != nil {
@@ -3118,7 +3220,7 @@ func (ec *executionContext) unmarshalInputSigninRequest(ctx context.Context, obj
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"email"}
+	fieldsInOrder := [...]string{"email", "password"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -3132,6 +3234,13 @@ func (ec *executionContext) unmarshalInputSigninRequest(ctx context.Context, obj
 				return it, err
 			}
 			it.Email = data
+		case "password":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("password"))
+			data, err := ec.unmarshalNString2string(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Password = data
 		}
 	}
 
@@ -3145,7 +3254,7 @@ func (ec *executionContext) unmarshalInputSignupRequest(ctx context.Context, obj
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"firstname", "lastname", "email"}
+	fieldsInOrder := [...]string{"firstname", "lastname", "email", "password"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -3173,6 +3282,13 @@ func (ec *executionContext) unmarshalInputSignupRequest(ctx context.Context, obj
 				return it, err
 			}
 			it.Email = data
+		case "password":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("password"))
+			data, err := ec.unmarshalNString2string(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Password = data
 		}
 	}
 
@@ -3475,6 +3591,8 @@ func (ec *executionContext) _SigninPayload(ctx context.Context, sel ast.Selectio
 			out.Values[i] = graphql.MarshalString("SigninPayload")
 		case "response":
 			out.Values[i] = ec._SigninPayload_response(ctx, field, obj)
+		case "token":
+			out.Values[i] = ec._SigninPayload_token(ctx, field, obj)
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -3511,6 +3629,8 @@ func (ec *executionContext) _SignupPayload(ctx context.Context, sel ast.Selectio
 			out.Values[i] = graphql.MarshalString("SignupPayload")
 		case "response":
 			out.Values[i] = ec._SignupPayload_response(ctx, field, obj)
+		case "token":
+			out.Values[i] = ec._SignupPayload_token(ctx, field, obj)
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -2,10 +2,6 @@
 
 package model
 
-import (
-	"github.com/google/uuid"
-)
-
 type Response interface {
 	IsResponse()
 }
@@ -29,27 +25,25 @@ type Query struct {
 
 type SigninPayload struct {
 	Response Response `json:"response,omitempty"`
+	// A token identifying the user to other services, sent back as a bearer token.
+	Token *string `json:"token,omitempty"`
 }
 
 type SigninRequest struct {
-	Email string `json:"email"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type SignupPayload struct {
 	Response Response `json:"response,omitempty"`
+	// A token identifying the new user to other services, sent back as a bearer token.
+	Token *string `json:"token,omitempty"`
 }
 
 type SignupRequest struct {
 	Firstname string `json:"firstname"`
 	Lastname  string `json:"lastname"`
 	Email     string `json:"email"`
+	// At least 8 characters. Only a hash of it is kept.
+	Password string `json:"password"`
 }
-
-type User struct {
-	ID        uuid.UUID `json:"id"`
-	Firstname string    `json:"firstname"`
-	Lastname  string    `json:"lastname"`
-	Email     string    `json:"email"`
-}
-
-func (User) IsResponse() {}
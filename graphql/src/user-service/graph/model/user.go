@@ -0,0 +1,18 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Firstname string    `json:"firstname"`
+	Lastname  string    `json:"lastname"`
+	Email     string    `json:"email"`
+
+	// PasswordHash is the bcrypt hash of the user's password. It is never
+	// sent to clients.
+	PasswordHash []byte `json:"-"`
+}
+
+func (User) IsResponse() {}
@@ -1,11 +1,31 @@
 package graph
 
-import "gorm.io/gorm"
+import (
+	"strings"
+	"user-service/auth"
+
+	"gorm.io/gorm"
+)
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	Db *gorm.DB
+	Db     *gorm.DB
+	Tokens *auth.Tokens
+
+	// Admins are the emails of the users who may list every user.
+	Admins []string
+}
+
+// isAdmin reports whether the signed in user is one of the Admins.
+func (r *Resolver) isAdmin(claims *auth.Claims) bool {
+	for _, admin := range r.Admins {
+		if strings.EqualFold(admin, claims.Email) {
+			return true
+		}
+	}
+
+	return false
 }
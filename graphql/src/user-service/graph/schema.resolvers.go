@@ -6,25 +6,37 @@ package graph
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"user-service/auth"
 	"user-service/graph/model"
 
-	"github.com/99designs/gqlgen/graphql"
+	"gorm.io/gorm"
 )
 
 // Signup is the resolver for the signup field.
 func (r *mutationResolver) Signup(ctx context.Context, input model.SignupRequest) (*model.SignupPayload, error) {
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	entity := model.User{
-		Firstname: input.Firstname,
-		Lastname:  input.Lastname,
-		Email:     input.Email,
+		Firstname:    input.Firstname,
+		Lastname:     input.Lastname,
+		Email:        input.Email,
+		PasswordHash: hash,
 	}
 
 	if err := r.Db.Model(model.User{}).Create(&entity).Error; err != nil {
 		return nil, err
 	}
 
-	res := model.SignupPayload{Response: entity}
+	token, err := r.Tokens.Issue(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	res := model.SignupPayload{Response: entity, Token: &token}
 
 	return &res, nil
 }
@@ -32,27 +44,54 @@ func (r *mutationResolver) Signup(ctx context.Context, input model.SignupRequest
 // Signin is the resolver for the signin field.
 func (r *mutationResolver) Signin(ctx context.Context, input model.SigninRequest) (*model.SigninPayload, error) {
 	var user model.User
-	if err := r.Db.Model(&model.User{}).Where("email = ?", input.Email).First(&user).Error; err != nil {
+	err := r.Db.Model(&model.User{}).Where("email = ?", input.Email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.CheckPassword(nil, input.Password)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	// cookie := &http.Cookie{Name: "cookie-name", Value: "test", Expires: time.Now().UTC().Add(24 * time.Hour)}
+	if err := auth.CheckPassword(&user, input.Password); err != nil {
+		return nil, err
+	}
 
-	rc := graphql.GetOperationContext(ctx)
-	rc.Headers.Add("test", "WOWWOWOWOWOOWOWOWOOWOWOWOWOOWOWOWOWOOW")
+	token, err := r.Tokens.Issue(user)
+	if err != nil {
+		return nil, err
+	}
 
-	res := model.SigninPayload{Response: user}
+	res := model.SigninPayload{Response: user, Token: &token}
 
 	return &res, nil
 }
 
 // Me is the resolver for the me field.
 func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
-	panic(fmt.Errorf("not implemented: Me - me"))
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	var user model.User
+	if err := r.Db.Model(&model.User{}).Where("id = ?", claims.Subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
 }
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	if !r.isAdmin(claims) {
+		return nil, auth.ErrForbidden
+	}
+
 	var users []*model.User
 	if err := r.Db.Model(model.User{}).Find(&users).Error; err != nil {
 		return nil, err
@@ -2,10 +2,15 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"service-kit/config"
+	"service-kit/health"
+	"service-kit/logging"
+	"strings"
+	"time"
+	"user-service/auth"
 	"user-service/graph"
 	"user-service/graph/model"
 
@@ -13,44 +18,57 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-const defaultPort = "8080"
+const (
+	// tokenTTL is how long a user stays signed in, matching the lifetime of
+	// a basket.
+	tokenTTL = 24 * time.Hour
+)
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
-	}
+	slog.SetDefault(logging.New(os.Stdout))
+
+	var cfg Config
+	config.LoadOrExit(&cfg)
+
+	tokens := auth.New([]byte(cfg.AuthSecret), tokenTTL)
 
-	db := connectToPostgres()
+	db := connectToPostgres(cfg.Postgres)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		logging.Fatal("could not get the postgres connection pool", err)
+	}
+	ready := health.NewChecker(2 * time.Second)
+	ready.Add("postgres", sqlDB.PingContext)
 
 	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
-		Db: db,
+		Db:     db,
+		Tokens: tokens,
+		Admins: cfg.Admins,
 	}}))
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/graphql"))
-	http.Handle("/graphql", srv)
+	http.Handle("/healthz", health.Live())
+	http.Handle("/readyz", ready)
+	http.Handle("/graphql", logging.Middleware(tokens.Middleware(srv)))
 
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	slog.Info(fmt.Sprintf("connect to http://localhost:%d/ for GraphQL playground", cfg.Port))
+	logging.Fatal("Server failed", http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), nil))
 }
 
-func connectToPostgres() *gorm.DB {
-	server := os.Getenv("POSTGRES_HOST")
-	port, err := strconv.Atoi(os.Getenv("POSTGRES_PORT"))
+func connectToPostgres(cfg config.Postgres) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(cfg.URL()), &gorm.Config{
+		Logger: logger.New(gormWriter{}, logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
 	if err != nil {
-		panic("Could not parse PORT to an integar")
-	}
-	name := os.Getenv("POSTGRES_DATABASE")
-	user := os.Getenv("POSTGRES_USERNAME")
-	pass := os.Getenv("POSTGRES_PASSWORD")
-
-	dsn := fmt.Sprintf("host=%s user=%s password='%s' dbname=%s port=%d", server, user, pass, name, port)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("could not connect to postgres: %v", err)
+		logging.Fatal("could not connect to postgres", err)
 	}
 
 	db.AutoMigrate(
@@ -59,3 +77,11 @@ func connectToPostgres() *gorm.DB {
 
 	return db
 }
+
+// gormWriter writes what gorm logs, slow queries and errors, as slog records
+// rather than coloured text.
+type gormWriter struct{}
+
+func (gormWriter) Printf(format string, args ...any) {
+	slog.Warn(strings.TrimSpace(fmt.Sprintf(format, args...)), slog.String("component", "gorm"))
+}
@@ -0,0 +1,23 @@
+package main
+
+import "service-kit/config"
+
+// Config is everything basket-service can be configured with. See config.Load
+// for where each value comes from.
+type Config struct {
+	Store    string          `yaml:"store" env:"BASKET_STORE" default:"redis" oneof:"redis postgres memory"`
+	Redis    config.Redis    `yaml:"redis"`
+	Postgres config.Postgres `yaml:"postgres"`
+}
+
+// Validate checks that whatever the chosen store needs is set.
+func (c Config) Validate() error {
+	switch c.Store {
+	case "redis":
+		return c.Redis.Require("redis")
+	case "postgres":
+		return c.Postgres.Require("postgres")
+	}
+
+	return nil
+}
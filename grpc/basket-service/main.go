@@ -2,36 +2,52 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
-	"strconv"
+	"strings"
+	"time"
+
+	"basket-service/store"
+	"service-kit/config"
+	"service-kit/health"
+	"service-kit/lifecycle"
+	"service-kit/logging"
 
-	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"github.com/rabbitmq/amqp091-go"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
 	pb "basket-service/protos"
 )
 
-type Basket struct {
-	ID    uuid.UUID    `json:"id"`
-	Items []BasketItem `json:"items"`
-}
+const (
+	basketTTL = 24 * time.Hour
 
-type BasketItem struct {
-	ID        uuid.UUID `json:"id"`
-	ProductId uuid.UUID `json:"catalogId"`
-	Price     float32   `json:"price"`
-	Quantity  uint      `json:"quantity"`
-}
+	// grpcPort is where the gRPC server listens, and so where the gateway
+	// dials it.
+	grpcPort = "50051"
+
+	// dialTimeout is how long the gateway waits to connect to the gRPC
+	// server.
+	dialTimeout = 10 * time.Second
+
+	// readinessInterval is how often the gRPC health service's status is
+	// checked.
+	readinessInterval = 5 * time.Second
+
+	// shutdownTimeout is how long in-flight requests have to finish, and
+	// connections to close, once the service is asked to stop.
+	shutdownTimeout = 20 * time.Second
+)
 
 type server struct {
 	pb.UnimplementedHelloServiceServer
@@ -46,89 +62,216 @@ func (s *server) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloRe
 }
 
 func main() {
-	lis, err := net.Listen("tcp", ":8080")
-	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
-	}
+	slog.SetDefault(logging.New(os.Stdout))
+
+	var cfg Config
+	config.LoadOrExit(&cfg)
+	lc := lifecycle.New(shutdownTimeout)
+	ready := health.NewChecker(2 * time.Second)
+
+	// no call uses the store yet; it is connected so that readiness covers it
+	connectToStore(cfg, lc, ready)
+
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	failOnError(err, "Failed to listen")
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.UnaryInterceptor(logCalls))
 	reflection.Register(s)
-	pb.RegisterHelloServiceServer(s, &server{})
+	pb.RegisterHelloServiceServer(s, NewServer())
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	lc.Go(func(ctx context.Context) {
+		ready.Watch(ctx, readinessInterval, servingStatus(healthServer, pb.HelloService_ServiceDesc.ServiceName))
+	})
 
 	go func() {
-		log.Println("Serving gRPC on 0.0.0.0:50051")
-		if err := s.Serve(lis); err != nil {
-			log.Fatalf("Failed to serve gRPC server: %v", err)
-		}
+		slog.Info("Serving gRPC on 0.0.0.0:" + grpcPort)
+		failOnError(s.Serve(lis), "Failed to serve gRPC server")
 	}()
+	lc.OnShutdown("gRPC server", gracefulStop(s))
+
+	// clients stop being sent here before the server stops
+	lc.OnShutdown("gRPC health", func(context.Context) error {
+		healthServer.Shutdown()
+		return nil
+	})
+
+	dialCtx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
 
 	conn, err := grpc.DialContext(
-		context.Background(),
-		"0.0.0.0:50051",
+		dialCtx,
+		"localhost:"+grpcPort,
 		grpc.WithBlock(),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
-	if err != nil {
-		log.Fatalln("Failed to dial server:", err)
-	}
+	failOnError(err, "Failed to dial server")
+	lc.OnShutdown("gRPC-Gateway connection", lifecycle.Close(conn.Close))
 
-	gwmux := runtime.NewServeMux()
-	err = pb.RegisterHelloServiceHandler(context.Background(), gwmux, conn)
-	if err != nil {
-		log.Fatalln("Failed to register gateway:", err)
+	// the request's ID and trace are passed on, so the gateway's and the
+	// server's records for a request share them
+	gwmux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(forwardHeader))
+	serveHTTP := func(h http.Handler) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			h.ServeHTTP(w, r)
+		}
 	}
+	failOnError(gwmux.HandlePath(http.MethodGet, "/healthz", serveHTTP(health.Live())), "Failed to register /healthz")
+	failOnError(gwmux.HandlePath(http.MethodGet, "/readyz", serveHTTP(ready)), "Failed to register /readyz")
+	err = pb.RegisterHelloServiceHandler(context.Background(), gwmux, conn)
+	failOnError(err, "Failed to register gateway")
 
 	gwServer := &http.Server{
 		Addr:    ":50052",
-		Handler: gwmux,
+		Handler: logging.Middleware(gwmux),
 	}
 
-	log.Println("Serving gRPC-Gateway for REST on http://0.0.0.0:50052")
-	if err := gwServer.ListenAndServe(); err != nil {
-		log.Fatalf("Failed to serve gRPC-Gateway server: %v", err)
+	slog.Info("Serving gRPC-Gateway for REST on http://0.0.0.0:50052")
+	if err := lc.Run(gwServer.ListenAndServe, gwServer.Shutdown); err != nil {
+		logging.Fatal("Shut down with errors", err)
 	}
 }
 
-func connectToRedis() *redis.Client {
-	server := os.Getenv("REDIS_HOST")
-	port, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
-	failOnError(err, "Could parse REDIS_PORT to int")
-	pass := os.Getenv("REDIS_PASSWORD")
+// servingStatus sets the serving status of the server as a whole, and of each
+// of services on s, to match a readiness report, so the same checks back
+// grpc.health.v1.
+func servingStatus(s *grpchealth.Server, services ...string) func(health.Report) {
+	return func(report health.Report) {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !report.Up() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", server, port),
-		Password: pass,
-		DB:       0,
-	})
+		// the empty name is the server as a whole
+		s.SetServingStatus("", status)
+		for _, service := range services {
+			s.SetServingStatus(service, status)
+		}
+	}
+}
 
-	return rdb
+// forwardHeader passes the request ID and trace context from gateway requests
+// to the gRPC server as metadata, along with the headers the gateway passes
+// on by default.
+func forwardHeader(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case strings.ToLower(logging.RequestIDHeader), logging.TraceparentHeader:
+		return strings.ToLower(key), true
+	default:
+		return runtime.DefaultHeaderMatcher(key)
+	}
 }
 
-func connectToRabbitMQ() *amqp091.Channel {
-	user := os.Getenv("RABBITMQ_USERNAME")
-	pass := os.Getenv("RABBITMQ_PASSWORD")
-	host := os.Getenv("RABBITMQ_HOST")
-	port := os.Getenv("RABBITMQ_PORT")
+// logCalls adds the call's request ID and trace, from its metadata, to the
+// records logged for it, sends the ID back in a header and logs one line once
+// the call has completed.
+func logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	// probes are not logged
+	if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.") {
+		return handler(ctx, req)
+	}
+
+	start := time.Now()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	id, attrs := logging.Request(first(md.Get(logging.RequestIDHeader)), first(md.Get(logging.TraceparentHeader)))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDHeader, id))
 
-	u := &url.URL{
-		Scheme: "amqp",
-		User:   url.UserPassword(user, pass),
-		Host:   fmt.Sprintf("%s:%s", host, port),
+	ctx = logging.WithAttrs(ctx, attrs...)
+	res, err := handler(ctx, req)
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
 	}
+	slog.Log(ctx, level, "call completed",
+		slog.String("method", info.FullMethod),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	return res, err
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
 
-	conn, err := amqp091.Dial(u.String())
-	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
+// gracefulStop stops s once its in-flight calls have finished, or stops it
+// straight away if they are still running when ctx is done.
+func gracefulStop(s *grpc.Server) func(context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// connectToStore returns the BasketStore for the configured store. The
+// connection is checked for readiness, and closed at shutdown.
+func connectToStore(cfg Config, lc *lifecycle.Manager, ready *health.Checker) store.BasketStore {
+	switch cfg.Store {
+	case "redis":
+		rdb := connectToRedis(cfg.Redis)
+		lc.OnShutdown("Redis", lifecycle.Close(rdb.Close))
+		ready.Add("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+		return store.NewRedisStore(rdb, basketTTL)
+	case "postgres":
+		pool := connectToPostgres(cfg.Postgres)
+		lc.OnShutdown("Postgres", func(context.Context) error {
+			pool.Close()
+			return nil
+		})
+		ready.Add("postgres", pool.Ping)
+		s, err := store.NewPostgresStore(context.Background(), pool, basketTTL)
+		failOnError(err, "Failed to create baskets table")
+		return s
+	case "memory":
+		return store.NewMemoryStore(basketTTL)
+	default:
+		// config.Load has already checked the store is one of the above
+		panic("unknown store " + cfg.Store)
+	}
+}
+
+func connectToRedis(cfg config.Redis) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr(),
+		Password: cfg.Password,
+		DB:       0,
+	})
+
+	return rdb
+}
 
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
+func connectToPostgres(cfg config.Postgres) *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), cfg.URL())
+	failOnError(err, "Failed to connect to Postgres")
 
-	return ch
+	return pool
 }
 
+// failOnError exits if the service could not start. It is only for startup;
+// calls report their errors to the caller.
 func failOnError(err error, msg string) {
 	if err != nil {
-		log.Panicf("%s: %s", msg, err)
+		logging.Fatal(msg, err)
 	}
 }
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+type Basket struct {
+	ID      uuid.UUID    `json:"id"`
+	OwnerId string       `json:"ownerId,omitempty"`
+	Items   []BasketItem `json:"items"`
+}
+
+type BasketItem struct {
+	ID        uuid.UUID   `json:"id"`
+	ProductId uuid.UUID   `json:"catalogId"`
+	Price     money.Money `json:"price"`
+	Quantity  uint        `json:"quantity"`
+}
@@ -0,0 +1,60 @@
+// Package moneyproto converts money.Money to and from the google.type.Money
+// message the gRPC API carries amounts in.
+package moneyproto
+
+import (
+	"fmt"
+	"math"
+
+	"money-go"
+
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+)
+
+const nanosPerUnit = 1_000_000_000
+
+// ToProto converts m to a google.type.Money message.
+func ToProto(m money.Money) *moneypb.Money {
+	scale := pow10(money.Exponent(m.Currency()))
+
+	return &moneypb.Money{
+		CurrencyCode: m.Currency(),
+		Units:        m.Minor() / scale,
+		Nanos:        int32(m.Minor() % scale * (nanosPerUnit / scale)),
+	}
+}
+
+// FromProto converts a google.type.Money message to Money. Nanos finer than
+// the currency's minor unit are rounded half to even, as money.Parse does.
+func FromProto(pb *moneypb.Money) (money.Money, error) {
+	if pb == nil {
+		return money.Money{}, nil
+	}
+
+	if pb.Nanos <= -nanosPerUnit || pb.Nanos >= nanosPerUnit ||
+		(pb.Units > 0 && pb.Nanos < 0) || (pb.Units < 0 && pb.Nanos > 0) {
+		return money.Money{}, fmt.Errorf("moneyproto: invalid google.type.Money units %d nanos %d", pb.Units, pb.Nanos)
+	}
+
+	sign := ""
+	units, nanos := pb.Units, int64(pb.Nanos)
+	if units < 0 || nanos < 0 {
+		sign = "-"
+		units, nanos = -units, -nanos
+	}
+
+	if units == math.MinInt64 {
+		return money.Money{}, money.ErrOverflow
+	}
+
+	return money.Parse(fmt.Sprintf("%s%d.%09d", sign, units, nanos), pb.CurrencyCode)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+
+	return p
+}
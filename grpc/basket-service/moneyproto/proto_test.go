@@ -0,0 +1,49 @@
+package moneyproto
+
+import (
+	"testing"
+
+	"money-go"
+
+	"github.com/stretchr/testify/require"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+)
+
+func Test_Proto(t *testing.T) {
+	tests := []struct {
+		amount money.Money
+		units  int64
+		nanos  int32
+	}{
+		{money.New(1234, "GBP"), 12, 340_000_000},
+		{money.New(-1234, "GBP"), -12, -340_000_000},
+		{money.New(5, "GBP"), 0, 50_000_000},
+		{money.New(1500, "JPY"), 1500, 0},
+		{money.New(1, "KWD"), 0, 1_000_000},
+	}
+
+	for _, test := range tests {
+		pb := ToProto(test.amount)
+		require.Equal(t, test.amount.Currency(), pb.CurrencyCode)
+		require.Equal(t, test.units, pb.Units)
+		require.Equal(t, test.nanos, pb.Nanos)
+
+		back, err := FromProto(pb)
+		require.NoError(t, err)
+		require.Equal(t, test.amount, back)
+	}
+}
+
+func Test_FromProto_Rounding(t *testing.T) {
+	// 0.125 and 0.135 GBP round half to even, as Parse does
+	m, err := FromProto(&moneypb.Money{CurrencyCode: "GBP", Nanos: 125_000_000})
+	require.NoError(t, err)
+	require.Equal(t, money.New(12, "GBP"), m)
+
+	m, err = FromProto(&moneypb.Money{CurrencyCode: "GBP", Nanos: 135_000_000})
+	require.NoError(t, err)
+	require.Equal(t, money.New(14, "GBP"), m)
+
+	_, err = FromProto(&moneypb.Money{CurrencyCode: "GBP", Units: 1, Nanos: -1})
+	require.Error(t, err)
+}
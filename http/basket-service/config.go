@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+
+	"service-kit/config"
+)
+
+// Config is everything basket-service can be configured with. See config.Load
+// for where each value comes from.
+type Config struct {
+	Store    string          `yaml:"store" env:"BASKET_STORE" default:"redis" oneof:"redis postgres memory"`
+	Redis    config.Redis    `yaml:"redis"`
+	Postgres config.Postgres `yaml:"postgres"`
+	RabbitMQ config.RabbitMQ `yaml:"rabbitmq"`
+
+	ProductServiceURL string `yaml:"productServiceUrl" env:"PRODUCT_SERVICE_BASE_URL" required:"true"`
+}
+
+// Validate checks that whatever the chosen store needs, and RabbitMQ, are set.
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Store {
+	case "redis":
+		errs = append(errs, c.Redis.Require("redis"))
+	case "postgres":
+		errs = append(errs, c.Postgres.Require("postgres"))
+	}
+
+	errs = append(errs, c.RabbitMQ.Require("rabbitmq"))
+
+	return errors.Join(errs...)
+}
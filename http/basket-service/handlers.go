@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"basket-service/model"
+	"basket-service/store"
+	"money-go"
+	"service-kit/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type CreateBasketRequest struct {
+	Items []CreateBasketItemRequest `json:"items"`
+}
+
+type CreateBasketItemRequest struct {
+	ProductId string `json:"catalogId"`
+	Quantity  uint   `json:"quantity"`
+}
+
+// ProductResponse is a product as product-service sends it. Price is kept as
+// the literal JSON number so that it converts to money.Money exactly.
+type ProductResponse struct {
+	Id          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       json.Number `json:"price"`
+}
+
+type handler struct {
+	baskets store.BasketStore
+	ch      *amqp091.Channel
+
+	// productsURL is product-service's base URL.
+	productsURL string
+}
+
+func (h *handler) getBasket(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse id to UUID"})
+	}
+
+	logBasket(c, id)
+
+	basket, err := h.baskets.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
+	}
+	if err != nil {
+		return fail(c, "Record invalid", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(basket)
+}
+
+func (h *handler) createBasket(c *fiber.Ctx) error {
+	var request CreateBasketRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	// todo: make better
+	if len(request.Items) <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "At least one product is required"})
+	}
+
+	for _, item := range request.Items {
+		_, err := uuid.Parse(item.ProductId)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Product id must be a valid id"})
+		}
+
+		if item.Quantity <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Quantity must be greater than 1"})
+		}
+	}
+
+	basket := model.Basket{
+		Id: uuid.New(),
+	}
+	logBasket(c, basket.Id)
+
+	for _, item := range request.Items {
+		uri := fmt.Sprintf("%s/api/products/%s", h.productsURL, item.ProductId)
+		res, err := http.Get(uri)
+		if err != nil {
+			return fail(c, "Could not validate product", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != fiber.StatusOK {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Invalid product"})
+		}
+
+		var product ProductResponse
+		if err := json.NewDecoder(res.Body).Decode(&product); err != nil {
+			return err
+		}
+
+		price, err := money.Parse(product.Price.String(), model.Currency)
+		if err != nil {
+			return fail(c, "Invalid product", err)
+		}
+
+		basketItem := model.BasketItem{
+			Id:        uuid.New(),
+			ProductId: uuid.MustParse(item.ProductId),
+			Price:     price,
+			Quantity:  item.Quantity,
+		}
+
+		basket.Items = append(basket.Items, basketItem)
+	}
+
+	if err := h.baskets.Create(c.UserContext(), &basket); err != nil {
+		return fail(c, "Could not save basket", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(basket)
+}
+
+func (h *handler) checkout(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse id to UUID"})
+	}
+
+	logBasket(c, id)
+
+	basket, err := h.baskets.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
+	}
+	if err != nil {
+		return fail(c, "Record invalid", err)
+	}
+
+	body, err := json.Marshal(basket)
+	if err != nil {
+		return fail(c, "Unable to checkout basket", err)
+	}
+
+	q, err := h.ch.QueueDeclare(
+		"orders", // name
+		true,     // durable
+		false,    // delete when unused
+		false,    // exclusive
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		return fail(c, "Unable to checkout basket", err)
+	}
+
+	if err := h.ch.PublishWithContext(c.UserContext(),
+		"",     // exchange
+		q.Name, // routing key
+		false,  // mandatory
+		false,  // immediate
+		amqp091.Publishing{
+			Body: body,
+		}); err != nil {
+		return fail(c, "Unable to checkout basket", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// fail logs err against the request and responds with a 500 and message.
+func fail(c *fiber.Ctx, message string, err error) error {
+	slog.ErrorContext(c.UserContext(), message, slog.Any("error", err))
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": message})
+}
+
+// logBasket adds the basket's ID to the records logged for the request.
+func logBasket(c *fiber.Ctx, id uuid.UUID) {
+	c.SetUserContext(logging.WithAttrs(c.UserContext(), slog.String(logging.BasketIDKey, id.String())))
+}
+
+// requestLogger adds the request's ID and trace to the records logged for it,
+// sends the ID back in the X-Request-ID header and logs one line once the
+// request has completed.
+func requestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id, attrs := logging.Request(c.Get(logging.RequestIDHeader), c.Get(logging.TraceparentHeader))
+		c.Set(logging.RequestIDHeader, id)
+		c.SetUserContext(logging.WithAttrs(c.UserContext(), attrs...))
+
+		// run the error handler now so the logged status matches the response
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		slog.InfoContext(c.UserContext(), "request completed",
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.String("path", c.Path()),
+			slog.Int("status", c.Response().StatusCode()),
+			slog.Duration("duration", time.Since(start)),
+		)
+
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is the version of the stored Basket shape written by
+// MarshalBinary. Bump it whenever that shape changes and register a migration
+// from the previous version in migrations.
+const SchemaVersion = 2
+
+// Currency is the currency of every basket. product-service prices carry no
+// currency of their own, so they are all taken to be in it.
+const Currency = "GBP"
+
+// migrations upgrade a basket decoded at version n (the map key) to version
+// n+1. UnmarshalBinary applies them in order until the basket reaches
+// SchemaVersion.
+var migrations = map[int]func(*Basket) error{
+	// baskets written before the schema was versioned have no schemaVersion
+	// but are otherwise identical to version 1
+	0: func(b *Basket) error { return nil },
+
+	// version 2 replaced float prices with money.Money; the old numbers decode
+	// exactly but without a currency
+	1: func(b *Basket) error {
+		for i := range b.Items {
+			b.Items[i].Price = b.Items[i].Price.WithCurrency(Currency)
+		}
+		return nil
+	},
+}
+
+type Basket struct {
+	SchemaVersion int          `json:"schemaVersion,omitempty"`
+	Id            uuid.UUID    `json:"id"`
+	OwnerId       string       `json:"ownerId,omitempty"`
+	Items         []BasketItem `json:"items"`
+}
+
+var (
+	_ encoding.BinaryMarshaler   = Basket{}
+	_ encoding.BinaryUnmarshaler = (*Basket)(nil)
+)
+
+// MarshalBinary encodes the basket as JSON stamped with the current
+// SchemaVersion. It lets a Basket be passed straight to go-redis.
+func (b Basket) MarshalBinary() ([]byte, error) {
+	b.SchemaVersion = SchemaVersion
+	return json.Marshal(b)
+}
+
+// UnmarshalBinary decodes a basket written by MarshalBinary at any schema
+// version up to SchemaVersion, migrating it to the current shape.
+func (b *Basket) UnmarshalBinary(data []byte) error {
+	var decoded Basket
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("basket %s has schema version %d, newest supported is %d", decoded.Id, decoded.SchemaVersion, SchemaVersion)
+	}
+
+	for decoded.SchemaVersion < SchemaVersion {
+		migrate, ok := migrations[decoded.SchemaVersion]
+		if !ok {
+			return fmt.Errorf("no migration for basket schema version %d", decoded.SchemaVersion)
+		}
+
+		if err := migrate(&decoded); err != nil {
+			return fmt.Errorf("migrating basket %s from schema version %d: %w", decoded.Id, decoded.SchemaVersion, err)
+		}
+
+		decoded.SchemaVersion++
+	}
+
+	*b = decoded
+	return nil
+}
+
+type BasketItem struct {
+	Id        uuid.UUID   `json:"id"`
+	ProductId uuid.UUID   `json:"catalogId"`
+	Price     money.Money `json:"price"`
+	Quantity  uint        `json:"quantity"`
+}
+
+var (
+	_ encoding.BinaryMarshaler   = BasketItem{}
+	_ encoding.BinaryUnmarshaler = (*BasketItem)(nil)
+)
+
+func (i BasketItem) MarshalBinary() ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (i *BasketItem) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, i)
+}
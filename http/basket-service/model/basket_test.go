@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"money-go"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return rdb
+}
+
+func Test_Basket_RedisRoundTrip(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	basket := Basket{
+		Id: uuid.New(),
+		Items: []BasketItem{
+			{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("25360.05", Currency), Quantity: 1},
+			{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("0.33", Currency), Quantity: 3},
+		},
+	}
+
+	require.NoError(t, rdb.Set(ctx, basket.Id.String(), basket, 24*time.Hour).Err())
+
+	var got Basket
+	require.NoError(t, rdb.Get(ctx, basket.Id.String()).Scan(&got))
+
+	basket.SchemaVersion = SchemaVersion
+	require.Equal(t, basket, got)
+}
+
+func Test_BasketItem_RedisRoundTrip(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	item := BasketItem{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("3.33", Currency), Quantity: 2}
+	require.NoError(t, rdb.Set(ctx, item.Id.String(), item, 0).Err())
+
+	var got BasketItem
+	require.NoError(t, rdb.Get(ctx, item.Id.String()).Scan(&got))
+	require.Equal(t, item, got)
+}
+
+func Test_Basket_MarshalBinary_StampsSchemaVersion(t *testing.T) {
+	data, err := Basket{Id: uuid.New()}.MarshalBinary()
+	require.NoError(t, err)
+	require.Contains(t, string(data), fmt.Sprintf(`"schemaVersion":%d`, SchemaVersion))
+}
+
+func Test_Basket_UnmarshalBinary_MigratesUnversioned(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	// the shape written by json.Marshal before baskets were versioned
+	legacy := `{"id":"0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10","items":[{"id":"5d3b9a3c-1f2e-4b6a-8c7d-9e0f1a2b3c4d","catalogId":"72119506-89ef-4c0c-ace7-6cbd984bfc50","price":10.5,"quantity":2}]}`
+	require.NoError(t, rdb.Set(ctx, "legacy", legacy, 0).Err())
+
+	var got Basket
+	require.NoError(t, rdb.Get(ctx, "legacy").Scan(&got))
+	require.Equal(t, SchemaVersion, got.SchemaVersion)
+	require.Equal(t, uuid.MustParse("0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10"), got.Id)
+	require.Len(t, got.Items, 1)
+	require.Equal(t, money.MustParse("10.50", Currency), got.Items[0].Price)
+	require.Equal(t, uint(2), got.Items[0].Quantity)
+}
+
+func Test_Basket_UnmarshalBinary_RejectsNewerSchema(t *testing.T) {
+	var got Basket
+	err := got.UnmarshalBinary([]byte(`{"schemaVersion":99,"id":"0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10","items":[]}`))
+	require.ErrorContains(t, err, "schema version 99")
+}
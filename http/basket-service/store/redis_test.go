@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"basket-service/model"
+	"money-go"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{
+		Id:      uuid.New(),
+		OwnerId: "alice",
+		Items:   []model.BasketItem{{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("9.99", model.Currency), Quantity: 2}},
+	}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.ErrorIs(t, s.Create(ctx, &basket), ErrAlreadyExists)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, basket.Items, got.Items)
+	require.Equal(t, model.SchemaVersion, got.SchemaVersion)
+
+	baskets, err := s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, baskets, 1)
+	require.Equal(t, basket.Id, baskets[0].Id)
+
+	mr.FastForward(time.Hour)
+
+	_, err = s.Get(ctx, basket.Id)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Update(ctx, &basket), ErrNotFound)
+}
@@ -0,0 +1,52 @@
+// Package store keeps baskets in one of the shared service-kit/basketstore
+// backends.
+package store
+
+import (
+	"context"
+	"time"
+
+	"basket-service/model"
+	"service-kit/basketstore"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotFound is returned when a basket does not exist or has expired.
+	ErrNotFound = basketstore.ErrNotFound
+
+	// ErrAlreadyExists is returned by Create when a live basket already has
+	// the same id.
+	ErrAlreadyExists = basketstore.ErrAlreadyExists
+)
+
+// BasketStore persists baskets. See basketstore.Store for how they expire.
+type BasketStore = basketstore.Store[model.Basket]
+
+// codec stores baskets in their versioned binary form, see model.Basket.MarshalBinary.
+var codec = basketstore.Codec[model.Basket]{
+	ID:    func(b *model.Basket) uuid.UUID { return b.Id },
+	Owner: func(b *model.Basket) string { return b.OwnerId },
+	Marshal: func(b *model.Basket) ([]byte, error) {
+		return b.MarshalBinary()
+	},
+	Unmarshal: func(data []byte, b *model.Basket) error {
+		return b.UnmarshalBinary(data)
+	},
+}
+
+func NewMemoryStore(ttl time.Duration) *basketstore.MemoryStore[model.Basket] {
+	return basketstore.NewMemoryStore(codec, ttl)
+}
+
+func NewRedisStore(rdb *redis.Client, ttl time.Duration) *basketstore.RedisStore[model.Basket] {
+	return basketstore.NewRedisStore(codec, rdb, ttl)
+}
+
+// NewPostgresStore creates the baskets table if it does not already exist.
+func NewPostgresStore(ctx context.Context, pool *pgxpool.Pool, ttl time.Duration) (*basketstore.PostgresStore[model.Basket], error) {
+	return basketstore.NewPostgresStore(ctx, codec, pool, ttl)
+}
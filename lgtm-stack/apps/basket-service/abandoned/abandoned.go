@@ -0,0 +1,298 @@
+// Package abandoned finds baskets that customers have stopped changing and
+// marks them abandoned, announcing each one with a basket.abandoned event so
+// marketing can follow up before the basket expires.
+//
+// It also moves on the other baskets nobody is going to touch again: ones left
+// checking out by a checkout that never finished are reopened, and ones
+// abandoned for long enough are marked expired.
+package abandoned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"basket-service/coupons"
+	"basket-service/model"
+	"basket-service/outbox"
+	"basket-service/pricing"
+	"basket-service/store"
+	"money-go"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "basket-service/abandoned"
+
+const (
+	// EventType is the type of the events the Worker raises.
+	EventType = "basket.abandoned"
+
+	// Exchange is the topic exchange basket events are published to, with
+	// their type as the routing key.
+	Exchange = "baskets"
+)
+
+// Event is the body of a basket.abandoned message.
+type Event struct {
+	BasketId    uuid.UUID          `json:"basketId"`
+	OwnerId     string             `json:"ownerId,omitempty"`
+	Items       []model.BasketItem `json:"items"`
+	Coupons     []string           `json:"coupons,omitempty"`
+	Total       money.Money        `json:"total"`
+	LastActive  time.Time          `json:"lastActive"`
+	AbandonedAt time.Time          `json:"abandonedAt"`
+}
+
+type Options struct {
+	// After is how long a basket has to go unchanged to be abandoned.
+	// Defaults to 2h.
+	After time.Duration
+
+	// CheckoutTimeout is how long a basket can be checking out before its
+	// checkout is taken to have died with the instance running it, and the
+	// basket is reopened. It must be longer than any checkout takes.
+	// Defaults to 10m.
+	CheckoutTimeout time.Duration
+
+	// ExpireAfter is how long a basket stays abandoned before it is marked
+	// expired. It must be shorter than the store's time to live, which would
+	// otherwise remove the basket first. Defaults to 22h.
+	ExpireAfter time.Duration
+
+	// Interval is how often baskets are checked. Defaults to 1m.
+	Interval time.Duration
+
+	// BatchSize is the most baskets read from the store at once. Defaults
+	// to 100.
+	BatchSize int
+}
+
+// Worker moves open baskets that have been idle for longer than
+// Options.After to abandoned. The status change and its event are written
+// together through the store's outbox, so each abandoned basket raises one
+// event however often the worker runs, and on however many instances.
+//
+// Baskets checking out for longer than Options.CheckoutTimeout are reopened,
+// and any coupons their checkout redeemed given back. Baskets abandoned for
+// longer than Options.ExpireAfter are marked expired, and since that is a
+// write, kept with their history for another time to live.
+type Worker struct {
+	store   store.BasketStore
+	coupons coupons.Store
+	pricing *pricing.Engine
+	opts    Options
+
+	abandoned metric.Float64Histogram
+
+	// now is swapped out in tests.
+	now func() time.Time
+}
+
+func NewWorker(store store.BasketStore, coupons coupons.Store, engine *pricing.Engine, opts Options) (*Worker, error) {
+	if opts.After <= 0 {
+		opts.After = 2 * time.Hour
+	}
+	if opts.CheckoutTimeout <= 0 {
+		opts.CheckoutTimeout = 10 * time.Minute
+	}
+	if opts.ExpireAfter <= 0 {
+		opts.ExpireAfter = 22 * time.Hour
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	abandoned, err := otel.Meter(instrumentationName).Float64Histogram("basket.abandoned.value",
+		metric.WithDescription("Total of each abandoned basket, in its currency"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Worker{
+		store:     store,
+		coupons:   coupons,
+		pricing:   engine,
+		opts:      opts,
+		abandoned: abandoned,
+		now:       time.Now,
+	}, nil
+}
+
+// Run checks for idle baskets until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Sweep(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to check for idle baskets", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep moves on every basket that is idle now: it reopens stalled checkouts,
+// expires baskets abandoned long enough and abandons idle open ones, and
+// returns how many baskets it moved. Expiry runs before abandoning, so a
+// basket is never abandoned and expired in the same sweep.
+func (w *Worker) Sweep(ctx context.Context) (int, error) {
+	passes := []struct {
+		status model.Status
+		after  time.Duration
+		move   func(ctx context.Context, basket *model.Basket, now time.Time) error
+	}{
+		{model.StatusCheckingOut, w.opts.CheckoutTimeout, w.reopen},
+		{model.StatusAbandoned, w.opts.ExpireAfter, w.expire},
+		{model.StatusOpen, w.opts.After, w.abandon},
+	}
+
+	total := 0
+	for _, pass := range passes {
+		moved, err := w.sweep(ctx, pass.status, pass.after, pass.move)
+		total += moved
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// sweep calls move for every basket in status that has been idle for longer
+// than after, a batch at a time, and returns how many it moved. A basket that
+// cannot be moved is logged and skipped, and the sweep stops once a whole
+// batch has been skipped.
+func (w *Worker) sweep(ctx context.Context, status model.Status, after time.Duration, move func(ctx context.Context, basket *model.Basket, now time.Time) error) (int, error) {
+	total := 0
+	for {
+		now := w.now()
+
+		baskets, err := w.store.Idle(ctx, status, now.Add(-after), w.opts.BatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		moved := 0
+		for _, basket := range baskets {
+			err := move(ctx, &basket, now)
+
+			// it was changed, or has gone, since it was read
+			if errors.Is(err, store.ErrConflict) || errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to move idle basket",
+					slog.String("basket_id", basket.Id.String()),
+					slog.String("status", string(status)),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
+			moved++
+		}
+
+		total += moved
+		if moved == 0 || len(baskets) < w.opts.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// reopen puts a basket whose checkout never finished back to open. The
+// checkout may have redeemed coupons before it died; they are only given back
+// once the basket is reopened, as until then the checkout could still write
+// its order.
+func (w *Worker) reopen(ctx context.Context, basket *model.Basket, now time.Time) error {
+	reason := fmt.Sprintf("checkout did not finish within %s", w.opts.CheckoutTimeout)
+	if err := basket.Transition(model.StatusOpen, model.ActorSystem, reason, now); err != nil {
+		return err
+	}
+
+	if err := w.store.Update(ctx, basket); err != nil {
+		return err
+	}
+
+	slog.WarnContext(ctx, "Reopened basket left checking out",
+		slog.String("basket_id", basket.Id.String()),
+	)
+
+	for _, code := range basket.Coupons {
+		if err := w.coupons.Release(ctx, code, basket.Id); err != nil {
+			slog.ErrorContext(ctx, "Failed to release coupon",
+				slog.String("coupon", code),
+				slog.String("basket_id", basket.Id.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// expire marks a basket that has stayed abandoned expired.
+func (w *Worker) expire(ctx context.Context, basket *model.Basket, now time.Time) error {
+	reason := fmt.Sprintf("abandoned for %s", w.opts.ExpireAfter)
+	if err := basket.Transition(model.StatusExpired, model.ActorSystem, reason, now); err != nil {
+		return err
+	}
+
+	return w.store.Update(ctx, basket)
+}
+
+func (w *Worker) abandon(ctx context.Context, basket *model.Basket, now time.Time) error {
+	breakdown, err := w.pricing.Price(basket, basket.Coupons)
+	if err != nil {
+		return fmt.Errorf("pricing basket: %w", err)
+	}
+
+	lastActive := basket.LastWritten()
+	reason := fmt.Sprintf("idle for %s", w.opts.After)
+	if err := basket.Transition(model.StatusAbandoned, model.ActorSystem, reason, now); err != nil {
+		return err
+	}
+
+	event, err := outbox.NewEvent(ctx, EventType, Exchange, EventType, Event{
+		BasketId:    basket.Id,
+		OwnerId:     basket.OwnerId,
+		Items:       basket.Items,
+		Coupons:     basket.Coupons,
+		Total:       breakdown.Total,
+		LastActive:  lastActive,
+		AbandonedAt: now.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := w.store.UpdateWithEvent(ctx, basket, event); err != nil {
+		return err
+	}
+
+	value, err := strconv.ParseFloat(breakdown.Total.Amount(), 64)
+	if err != nil {
+		return err
+	}
+
+	w.abandoned.Record(ctx, value, metric.WithAttributes(
+		attribute.String("currency", breakdown.Total.Currency()),
+		attribute.String("region", breakdown.Region),
+	))
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package abandoned
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"basket-service/coupons"
+	"basket-service/model"
+	"basket-service/pricing"
+	"basket-service/store"
+	"money-go"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Worker_Sweep(t *testing.T) {
+	s := store.NewMemoryStore(24 * time.Hour)
+	engine, err := pricing.New(model.Currency, pricing.DefaultConfig(model.Currency))
+	require.NoError(t, err)
+
+	w, err := NewWorker(s, coupons.NewMemoryStore(), engine, Options{After: time.Hour, BatchSize: 1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	create := func(status model.Status) model.Basket {
+		basket := model.Basket{Id: uuid.New(), Status: status, OwnerId: "alice"}
+		basket.AddItem(uuid.New(), money.MustParse("60.00", model.Currency), 1)
+		require.NoError(t, s.Create(ctx, &basket))
+		return basket
+	}
+
+	first, second := create(model.StatusOpen), create(model.StatusOpen)
+	create(model.StatusCheckedOut)
+
+	// nothing has been idle for long enough yet
+	abandoned, err := w.Sweep(ctx)
+	require.NoError(t, err)
+	require.Zero(t, abandoned)
+
+	now := time.Now().Add(2 * time.Hour)
+	w.now = func() time.Time { return now }
+
+	abandoned, err = w.Sweep(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, abandoned)
+
+	got, err := s.Get(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusAbandoned, got.Status)
+	require.Equal(t, model.Transition{From: model.StatusOpen, To: model.StatusAbandoned, At: now.UTC(), Actor: model.ActorSystem, Reason: "idle for 1h0m0s"}, got.History[0])
+
+	events, err := s.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, EventType, events[0].Type)
+	require.Equal(t, Exchange, events[0].Exchange)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(events[0].Body, &event))
+	require.Contains(t, []uuid.UUID{first.Id, second.Id}, event.BasketId)
+	require.Equal(t, "alice", event.OwnerId)
+	require.Equal(t, money.MustParse("60.00", model.Currency), event.Total)
+	require.False(t, event.LastActive.IsZero())
+
+	// baskets are only abandoned once
+	abandoned, err = w.Sweep(ctx)
+	require.NoError(t, err)
+	require.Zero(t, abandoned)
+}
+
+func Test_Worker_Sweep_StalledAndExpired(t *testing.T) {
+	s := store.NewMemoryStore(24 * time.Hour)
+	couponStore := coupons.NewMemoryStore()
+	engine, err := pricing.New(model.Currency, pricing.DefaultConfig(model.Currency))
+	require.NoError(t, err)
+
+	w, err := NewWorker(s, couponStore, engine, Options{After: time.Hour, CheckoutTimeout: 10 * time.Minute, ExpireAfter: 20 * time.Minute})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, couponStore.Put(ctx, coupons.Coupon{Code: "ONCE", MaxRedemptions: 1}))
+
+	// a checkout that redeemed its coupon and then died
+	stalled := model.Basket{Id: uuid.New(), Status: model.StatusCheckingOut, Coupons: []string{"ONCE"}}
+	require.NoError(t, s.Create(ctx, &stalled))
+	require.NoError(t, couponStore.Redeem(ctx, "ONCE", "", stalled.Id, time.Now()))
+
+	left := model.Basket{Id: uuid.New(), Status: model.StatusAbandoned}
+	require.NoError(t, s.Create(ctx, &left))
+
+	now := time.Now().Add(30 * time.Minute)
+	w.now = func() time.Time { return now }
+
+	moved, err := w.Sweep(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, moved)
+
+	got, err := s.Get(ctx, stalled.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusOpen, got.Status)
+	require.Equal(t, model.Transition{From: model.StatusCheckingOut, To: model.StatusOpen, At: now.UTC(), Actor: model.ActorSystem, Reason: "checkout did not finish within 10m0s"}, got.History[0])
+
+	coupon, err := couponStore.Get(ctx, "ONCE")
+	require.NoError(t, err)
+	require.Zero(t, coupon.Redemptions, "the stalled checkout's coupon is given back")
+
+	// expired baskets are kept, with how they ended
+	got, err = s.Get(ctx, left.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusExpired, got.Status)
+	require.Equal(t, "abandoned for 20m0s", got.History[0].Reason)
+
+	events, err := s.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, events, "only abandoning raises an event")
+}
@@ -0,0 +1,101 @@
+// Package auth identifies who a request is from, using the bearer token
+// user-service issues at sign in. Tokens are JWTs signed with HS256 using a
+// secret shared with user-service; their subject is the user's id.
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer is the iss claim of tokens from user-service.
+const Issuer = "user-service"
+
+// ErrNotConfigured is returned by Verify when there is no secret to check
+// tokens with.
+var ErrNotConfigured = errors.New("no secret to verify tokens with")
+
+// Identity is the signed in user a request is from.
+type Identity struct {
+	UserId string
+	Email  string
+}
+
+type claims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// Verifier checks tokens against the secret shared with user-service. A
+// Verifier without a secret rejects every token, so baskets can still be used
+// anonymously.
+type Verifier struct {
+	secret []byte
+}
+
+func NewVerifier(secret []byte) *Verifier {
+	return &Verifier{secret: secret}
+}
+
+// Verify checks a token's signature, issuer and expiry and returns who it
+// identifies.
+func (v *Verifier) Verify(token string) (Identity, error) {
+	if len(v.secret) == 0 {
+		return Identity{}, ErrNotConfigured
+	}
+
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return v.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if c.Subject == "" {
+		return Identity{}, errors.New("token has no subject")
+	}
+
+	return Identity{UserId: c.Subject, Email: c.Email}, nil
+}
+
+type localsKey struct{}
+
+// Middleware identifies the user of requests with an Authorization bearer
+// token, for FromContext. Requests without one are anonymous; a token that is
+// not valid is rejected with a 401 so the client knows to sign in again.
+func Middleware(v *Verifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Authorization must be a bearer token"})
+		}
+
+		identity, err := v.Verify(token)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token is not valid, sign in again"})
+		}
+
+		c.Locals(localsKey{}, identity)
+		return c.Next()
+	}
+}
+
+// FromContext returns the identity Middleware found on the request, or false
+// if the request is anonymous.
+func FromContext(c *fiber.Ctx) (Identity, bool) {
+	identity, ok := c.Locals(localsKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("test-secret")
+
+func sign(t *testing.T, key []byte, c claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(key)
+	require.NoError(t, err)
+
+	return token
+}
+
+func valid(subject string) claims {
+	return claims{
+		Email: subject + "@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func Test_Verifier_Verify(t *testing.T) {
+	v := NewVerifier(secret)
+
+	identity, err := v.Verify(sign(t, secret, valid("alice")))
+	require.NoError(t, err)
+	require.Equal(t, Identity{UserId: "alice", Email: "alice@example.com"}, identity)
+
+	_, err = v.Verify(sign(t, []byte("other-secret"), valid("alice")))
+	require.Error(t, err)
+
+	expired := valid("alice")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	_, err = v.Verify(sign(t, secret, expired))
+	require.Error(t, err)
+
+	forever := valid("alice")
+	forever.ExpiresAt = nil
+	_, err = v.Verify(sign(t, secret, forever))
+	require.Error(t, err)
+
+	elsewhere := valid("alice")
+	elsewhere.Issuer = "somewhere-else"
+	_, err = v.Verify(sign(t, secret, elsewhere))
+	require.Error(t, err)
+
+	_, err = NewVerifier(nil).Verify(sign(t, secret, valid("alice")))
+	require.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func Test_Middleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(NewVerifier(secret)))
+	app.Get("/", func(c *fiber.Ctx) error {
+		identity, ok := FromContext(c)
+		if !ok {
+			return c.SendString("anonymous")
+		}
+		return c.SendString(identity.UserId)
+	})
+
+	send := func(authorization string) (int, string) {
+		req := httptest.NewRequest("GET", "/", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		res, err := app.Test(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		return res.StatusCode, string(body)
+	}
+
+	code, body := send("")
+	require.Equal(t, fiber.StatusOK, code)
+	require.Equal(t, "anonymous", body)
+
+	code, body = send("Bearer " + sign(t, secret, valid("alice")))
+	require.Equal(t, fiber.StatusOK, code)
+	require.Equal(t, "alice", body)
+
+	code, _ = send("Bearer not-a-token")
+	require.Equal(t, fiber.StatusUnauthorized, code)
+
+	code, _ = send("Basic YWxpY2U6c2VjcmV0")
+	require.Equal(t, fiber.StatusUnauthorized, code)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"money-go"
+	"service-kit/config"
+)
+
+// Config is everything basket-service can be configured with. See config.Load
+// for where each value comes from; the env tags keep the variables the service
+// has always read.
+type Config struct {
+	Port int `yaml:"port" env:"PORT" default:"8080"`
+
+	// Store is where baskets are kept. Idempotency keys are short lived, so
+	// with "postgres" they are still kept in Redis.
+	Store    string          `yaml:"store" env:"BASKET_STORE" default:"redis" oneof:"redis postgres memory"`
+	Redis    config.Redis    `yaml:"redis"`
+	Postgres config.Postgres `yaml:"postgres"`
+	RabbitMQ config.RabbitMQ `yaml:"rabbitmq"`
+
+	ProductServiceURL string `yaml:"productServiceUrl" env:"PRODUCT_SERVICE_BASE_URL" required:"true"`
+
+	// AuthSecret verifies the tokens user-service issues. Without it only
+	// anonymous baskets can be used.
+	AuthSecret string `yaml:"authSecret" env:"AUTH_SECRET" secret:"true"`
+
+	// PricingConfig is a JSON file of tax, shipping and promotion rules, and
+	// CouponsFile a JSON file of coupons to load at startup.
+	PricingConfig string `yaml:"pricingConfig" env:"PRICING_CONFIG"`
+	CouponsFile   string `yaml:"couponsFile" env:"COUPONS_FILE"`
+
+	// AbandonedAfter, CheckoutTimeout and ExpireAfter are how long a basket
+	// can sit open, checking out or abandoned before the abandoned basket
+	// worker moves it on (see abandoned.Options).
+	AbandonedAfter  time.Duration `yaml:"abandonedAfter" env:"ABANDONED_AFTER" default:"2h"`
+	CheckoutTimeout time.Duration `yaml:"checkoutTimeout" env:"CHECKOUT_TIMEOUT" default:"10m"`
+	ExpireAfter     time.Duration `yaml:"expireAfter" env:"EXPIRE_AFTER" default:"22h"`
+
+	PriceDriftTolerance money.Rate    `yaml:"priceDriftTolerance" env:"PRICE_DRIFT_TOLERANCE" default:"0"`
+	ShutdownTimeout     time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" default:"20s"`
+}
+
+// Validate checks that whatever the chosen store needs is set, and that
+// baskets are expired before the store removes them.
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Store {
+	case "redis":
+		errs = append(errs, c.Redis.Require("redis"))
+	case "postgres":
+		errs = append(errs, c.Postgres.Require("postgres"), c.Redis.Require("redis"))
+	}
+
+	errs = append(errs, c.RabbitMQ.Require("rabbitmq"))
+
+	if c.ExpireAfter >= basketTTL {
+		errs = append(errs, fmt.Errorf("expireAfter must be shorter than the basket time to live of %s (set EXPIRE_AFTER)", basketTTL))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+// Package coupons keeps track of coupon codes: when they can be used, how many
+// times, and who has used them.
+//
+// What a coupon takes off a basket is a pricing rule (see pricing.Coupon);
+// this package only decides whether it may be used.
+package coupons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned for a code that is not a coupon.
+	ErrNotFound = errors.New("coupon not found")
+
+	// ErrNotActive is returned outside a coupon's validity window.
+	ErrNotActive = errors.New("coupon is not active")
+
+	// ErrExhausted is returned once a coupon has been redeemed as many times
+	// as it may be.
+	ErrExhausted = errors.New("coupon has been used up")
+
+	// ErrUserLimit is returned once a customer has redeemed a coupon as many
+	// times as one customer may.
+	ErrUserLimit = errors.New("coupon has been used too many times by this customer")
+
+	// ErrOwnerRequired is returned for a coupon limited per customer when the
+	// basket has no owner to count it against.
+	ErrOwnerRequired = errors.New("coupon can only be used by a signed in customer")
+)
+
+// Coupon is a code's terms and how often it has been redeemed. Zero values
+// mean no limit: a nil ValidFrom or ValidUntil leaves that end of the window
+// open, and a zero MaxRedemptions or MaxPerUser allows any number.
+type Coupon struct {
+	Code           string     `json:"code"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ValidUntil     *time.Time `json:"validUntil,omitempty"`
+	MaxRedemptions int64      `json:"maxRedemptions,omitempty"`
+	MaxPerUser     int64      `json:"maxPerUser,omitempty"`
+
+	// Redemptions is filled in by Store.Get and ignored by Store.Put.
+	Redemptions int64 `json:"redemptions,omitempty"`
+}
+
+// Check reports why the coupon could not be redeemed at, ignoring per-customer
+// limits.
+func (c Coupon) Check(at time.Time) error {
+	if c.ValidFrom != nil && at.Before(*c.ValidFrom) {
+		return ErrNotActive
+	}
+
+	if c.ValidUntil != nil && !at.Before(*c.ValidUntil) {
+		return ErrNotActive
+	}
+
+	if c.MaxRedemptions > 0 && c.Redemptions >= c.MaxRedemptions {
+		return ErrExhausted
+	}
+
+	return nil
+}
+
+// Normalize returns code as coupons are stored, trimmed and upper case, so
+// customers can type it however they like.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Store holds coupons and their redemptions.
+//
+// Redeem is atomic: concurrent checkouts can never take a coupon past its
+// limits. A redemption is recorded against a basket, so redeeming the same
+// coupon for the same basket again is a no-op and Release can undo it if the
+// checkout goes on to fail.
+type Store interface {
+	Get(ctx context.Context, code string) (*Coupon, error)
+
+	// Put creates a coupon or replaces its terms, keeping its redemptions.
+	Put(ctx context.Context, coupon Coupon) error
+
+	// Redeem records that userId used the coupon for basketId at the given
+	// time, if its terms allow it. userId may be empty for an anonymous
+	// basket, which is only allowed for coupons without a per-customer limit.
+	Redeem(ctx context.Context, code, userId string, basketId uuid.UUID, at time.Time) error
+
+	// Release undoes a redemption made for basketId, if there is one.
+	Release(ctx context.Context, code string, basketId uuid.UUID) error
+}
+
+// LoadFile reads a JSON list of coupons, e.g.
+//
+//	[{"code": "WELCOME10", "validUntil": "2026-12-31T23:59:59Z", "maxRedemptions": 1000, "maxPerUser": 1}]
+func LoadFile(path string) ([]Coupon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var coupons []Coupon
+	if err := json.Unmarshal(data, &coupons); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i := range coupons {
+		coupons[i].Code = Normalize(coupons[i].Code)
+		if coupons[i].Code == "" {
+			return nil, fmt.Errorf("%s: coupon %d has no code", path, i)
+		}
+	}
+
+	return coupons, nil
+}
@@ -0,0 +1,159 @@
+package coupons
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	now   = time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	later = now.Add(time.Hour)
+)
+
+// testStore checks the behaviour every Store must share.
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "NOPE")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Redeem(ctx, "NOPE", "alice", uuid.New(), now), ErrNotFound)
+
+	t.Run("window", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, Coupon{Code: "AUTUMN", ValidFrom: &now, ValidUntil: &later}))
+
+		require.ErrorIs(t, s.Redeem(ctx, "AUTUMN", "alice", uuid.New(), now.Add(-time.Second)), ErrNotActive)
+		require.ErrorIs(t, s.Redeem(ctx, "AUTUMN", "alice", uuid.New(), now.Add(time.Hour)), ErrNotActive)
+		require.NoError(t, s.Redeem(ctx, "AUTUMN", "alice", uuid.New(), now))
+	})
+
+	t.Run("cap", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, Coupon{Code: "FIRST2", MaxRedemptions: 2}))
+
+		basket := uuid.New()
+		require.NoError(t, s.Redeem(ctx, "FIRST2", "", basket, now))
+
+		// the same basket again is not another redemption
+		require.NoError(t, s.Redeem(ctx, "FIRST2", "", basket, now))
+		require.NoError(t, s.Redeem(ctx, "FIRST2", "", uuid.New(), now))
+		require.ErrorIs(t, s.Redeem(ctx, "FIRST2", "", uuid.New(), now), ErrExhausted)
+
+		coupon, err := s.Get(ctx, "FIRST2")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), coupon.Redemptions)
+
+		// releasing makes room again, and releasing twice changes nothing
+		require.NoError(t, s.Release(ctx, "FIRST2", basket))
+		require.NoError(t, s.Release(ctx, "FIRST2", basket))
+
+		coupon, err = s.Get(ctx, "FIRST2")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), coupon.Redemptions)
+		require.NoError(t, s.Redeem(ctx, "FIRST2", "", uuid.New(), now))
+
+		// changing the terms keeps the redemptions
+		require.NoError(t, s.Put(ctx, Coupon{Code: "FIRST2", MaxRedemptions: 3}))
+		coupon, err = s.Get(ctx, "FIRST2")
+		require.NoError(t, err)
+		require.Equal(t, Coupon{Code: "FIRST2", MaxRedemptions: 3, Redemptions: 2}, *coupon)
+	})
+
+	t.Run("per user", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, Coupon{Code: "WELCOME10", MaxPerUser: 1}))
+
+		require.ErrorIs(t, s.Redeem(ctx, "WELCOME10", "", uuid.New(), now), ErrOwnerRequired)
+
+		basket := uuid.New()
+		require.NoError(t, s.Redeem(ctx, "WELCOME10", "alice", basket, now))
+		require.ErrorIs(t, s.Redeem(ctx, "WELCOME10", "alice", uuid.New(), now), ErrUserLimit)
+		require.NoError(t, s.Redeem(ctx, "WELCOME10", "bob", uuid.New(), now))
+
+		require.NoError(t, s.Release(ctx, "WELCOME10", basket))
+		require.NoError(t, s.Redeem(ctx, "WELCOME10", "alice", uuid.New(), now))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, Coupon{Code: "FLASH", MaxRedemptions: 5}))
+
+		var wg sync.WaitGroup
+		var redeemed atomic.Int64
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if s.Redeem(ctx, "FLASH", "", uuid.New(), now) == nil {
+					redeemed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, int64(5), redeemed.Load())
+	})
+}
+
+func Test_MemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func Test_RedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb)
+	testStore(t, s)
+
+	require.NoError(t, s.Put(context.Background(), Coupon{Code: "DATED", ValidFrom: &now, ValidUntil: &later}))
+	coupon, err := s.Get(context.Background(), "DATED")
+	require.NoError(t, err)
+	require.Equal(t, now, *coupon.ValidFrom)
+	require.Equal(t, later, *coupon.ValidUntil)
+}
+
+func Test_Coupon_Check(t *testing.T) {
+	coupon := Coupon{ValidFrom: &now, ValidUntil: &later, MaxRedemptions: 1}
+
+	require.ErrorIs(t, coupon.Check(now.Add(-time.Second)), ErrNotActive)
+	require.NoError(t, coupon.Check(now))
+	require.ErrorIs(t, coupon.Check(now.Add(time.Hour)), ErrNotActive)
+
+	coupon.Redemptions = 1
+	require.ErrorIs(t, coupon.Check(now), ErrExhausted)
+
+	require.NoError(t, Coupon{}.Check(now))
+}
+
+func Test_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coupons.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"code": " welcome10 ", "maxPerUser": 1},
+		{"code": "AUTUMN", "validUntil": "2026-12-01T00:00:00Z", "maxRedemptions": 1000}
+	]`), 0o644))
+
+	coupons, err := LoadFile(path)
+	require.NoError(t, err)
+	until := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []Coupon{
+		{Code: "WELCOME10", MaxPerUser: 1},
+		{Code: "AUTUMN", ValidUntil: &until, MaxRedemptions: 1000},
+	}, coupons)
+
+	data, err := json.Marshal(coupons[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"code":"WELCOME10","maxPerUser":1}`, string(data), "an open window is left out")
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"maxPerUser": 1}]`), 0o644))
+	_, err = LoadFile(path)
+	require.Error(t, err)
+}
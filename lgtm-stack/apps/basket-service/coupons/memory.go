@@ -0,0 +1,117 @@
+package coupons
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type redemption struct {
+	userId string
+	at     time.Time
+}
+
+type memoryCoupon struct {
+	coupon      Coupon
+	redemptions map[uuid.UUID]redemption
+	users       map[string]int64
+}
+
+// MemoryStore is a Store held in process memory. It is intended for tests and
+// local runs; nothing survives a restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	coupons map[string]*memoryCoupon
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{coupons: make(map[string]*memoryCoupon)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, code string) (*Coupon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.coupons[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	coupon := entry.coupon
+	coupon.Redemptions = int64(len(entry.redemptions))
+	return &coupon, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, coupon Coupon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon.Redemptions = 0
+	if entry, ok := s.coupons[coupon.Code]; ok {
+		entry.coupon = coupon
+		return nil
+	}
+
+	s.coupons[coupon.Code] = &memoryCoupon{
+		coupon:      coupon,
+		redemptions: make(map[uuid.UUID]redemption),
+		users:       make(map[string]int64),
+	}
+	return nil
+}
+
+func (s *MemoryStore) Redeem(ctx context.Context, code, userId string, basketId uuid.UUID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.coupons[code]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if _, ok := entry.redemptions[basketId]; ok {
+		return nil
+	}
+
+	coupon := entry.coupon
+	coupon.Redemptions = int64(len(entry.redemptions))
+	if err := coupon.Check(at); err != nil {
+		return err
+	}
+
+	if coupon.MaxPerUser > 0 {
+		if userId == "" {
+			return ErrOwnerRequired
+		}
+		if entry.users[userId] >= coupon.MaxPerUser {
+			return ErrUserLimit
+		}
+	}
+
+	entry.redemptions[basketId] = redemption{userId: userId, at: at}
+	entry.users[userId]++
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, code string, basketId uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.coupons[code]
+	if !ok {
+		return nil
+	}
+
+	r, ok := entry.redemptions[basketId]
+	if !ok {
+		return nil
+	}
+
+	delete(entry.redemptions, basketId)
+	entry.users[r.userId]--
+	return nil
+}
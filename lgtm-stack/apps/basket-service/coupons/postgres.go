@@ -0,0 +1,155 @@
+package coupons
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS coupons (
+	code            text PRIMARY KEY,
+	valid_from      timestamptz,
+	valid_until     timestamptz,
+	max_redemptions bigint NOT NULL DEFAULT 0,
+	max_per_user    bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+	code        text NOT NULL REFERENCES coupons (code),
+	basket_id   uuid NOT NULL,
+	user_id     text NOT NULL,
+	redeemed_at timestamptz NOT NULL,
+	PRIMARY KEY (code, basket_id)
+);
+
+CREATE INDEX IF NOT EXISTS coupon_redemptions_user_idx ON coupon_redemptions (code, user_id);
+`
+
+// PostgresStore is a Store backed by a "coupons" table of terms and a
+// "coupon_redemptions" table with a row per redemption.
+//
+// Redeem locks the coupon's row for the length of its transaction, so
+// concurrent redemptions of the same coupon are checked one at a time.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+// NewPostgresStore creates the coupons tables if they do not already exist.
+func NewPostgresStore(ctx context.Context, pool *pgxpool.Pool) (*PostgresStore, error) {
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, code string) (*Coupon, error) {
+	return get(ctx, s.pool, code, "")
+}
+
+func (s *PostgresStore) Put(ctx context.Context, coupon Coupon) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO coupons (code, valid_from, valid_until, max_redemptions, max_per_user)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (code) DO UPDATE
+			SET valid_from = EXCLUDED.valid_from, valid_until = EXCLUDED.valid_until,
+				max_redemptions = EXCLUDED.max_redemptions, max_per_user = EXCLUDED.max_per_user`,
+		coupon.Code, coupon.ValidFrom, coupon.ValidUntil, coupon.MaxRedemptions, coupon.MaxPerUser,
+	)
+
+	return err
+}
+
+func (s *PostgresStore) Redeem(ctx context.Context, code, userId string, basketId uuid.UUID, at time.Time) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		coupon, err := get(ctx, tx, code, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		var redeemed bool
+		err = tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM coupon_redemptions WHERE code = $1 AND basket_id = $2)`,
+			code, basketId,
+		).Scan(&redeemed)
+		if err != nil {
+			return err
+		}
+
+		if redeemed {
+			return nil
+		}
+
+		if err := coupon.Check(at); err != nil {
+			return err
+		}
+
+		if coupon.MaxPerUser > 0 {
+			if userId == "" {
+				return ErrOwnerRequired
+			}
+
+			var used int64
+			err := tx.QueryRow(ctx,
+				`SELECT count(*) FROM coupon_redemptions WHERE code = $1 AND user_id = $2`,
+				code, userId,
+			).Scan(&used)
+			if err != nil {
+				return err
+			}
+
+			if used >= coupon.MaxPerUser {
+				return ErrUserLimit
+			}
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO coupon_redemptions (code, basket_id, user_id, redeemed_at) VALUES ($1, $2, $3, $4)`,
+			code, basketId, userId, at,
+		)
+
+		return err
+	})
+}
+
+func (s *PostgresStore) Release(ctx context.Context, code string, basketId uuid.UUID) error {
+	_, err := s.pool.Exec(ctx,
+		`DELETE FROM coupon_redemptions WHERE code = $1 AND basket_id = $2`,
+		code, basketId,
+	)
+
+	return err
+}
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// get reads a coupon with its redemption count. lock is appended to the
+// query, e.g. "FOR UPDATE" inside a transaction.
+func get(ctx context.Context, db querier, code, lock string) (*Coupon, error) {
+	coupon := Coupon{Code: code}
+
+	err := db.QueryRow(ctx,
+		`SELECT valid_from, valid_until, max_redemptions, max_per_user,
+			(SELECT count(*) FROM coupon_redemptions r WHERE r.code = c.code)
+		FROM coupons c WHERE code = $1 `+lock,
+		code,
+	).Scan(&coupon.ValidFrom, &coupon.ValidUntil, &coupon.MaxRedemptions, &coupon.MaxPerUser, &coupon.Redemptions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
@@ -0,0 +1,180 @@
+package coupons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store backed by Redis. Each coupon is a hash under
+// "coupon:{code}" holding its terms (times as Unix milliseconds, 0 for none)
+// and redemption count. Who redeemed it is kept in "coupon:{code}:users",
+// counts per user, and "coupon:{code}:baskets", the user for each basket.
+//
+// Redeem and Release are Lua scripts, so checking the limits and recording a
+// redemption happen as one atomic step.
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+var _ Store = (*RedisStore)(nil)
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func couponKeys(code string) []string {
+	key := fmt.Sprintf("coupon:%s", code)
+	return []string{key, key + ":users", key + ":baskets"}
+}
+
+func (s *RedisStore) Get(ctx context.Context, code string) (*Coupon, error) {
+	values, err := s.rdb.HGetAll(ctx, couponKeys(code)[0]).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+
+	fields := map[string]int64{}
+	for _, name := range []string{"validFrom", "validUntil", "maxRedemptions", "maxPerUser", "redemptions"} {
+		if fields[name], err = strconv.ParseInt(values[name], 10, 64); err != nil {
+			return nil, fmt.Errorf("coupon %s has an invalid %s: %w", code, name, err)
+		}
+	}
+
+	return &Coupon{
+		Code:           code,
+		ValidFrom:      fromMillis(fields["validFrom"]),
+		ValidUntil:     fromMillis(fields["validUntil"]),
+		MaxRedemptions: fields["maxRedemptions"],
+		MaxPerUser:     fields["maxPerUser"],
+		Redemptions:    fields["redemptions"],
+	}, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, coupon Coupon) error {
+	key := couponKeys(coupon.Code)[0]
+
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"validFrom", toMillis(coupon.ValidFrom),
+			"validUntil", toMillis(coupon.ValidUntil),
+			"maxRedemptions", coupon.MaxRedemptions,
+			"maxPerUser", coupon.MaxPerUser,
+		)
+		pipe.HSetNX(ctx, key, "redemptions", 0)
+		return nil
+	})
+
+	return err
+}
+
+// redeemScript returns "ok" once the redemption is recorded, or why it could
+// not be.
+var redeemScript = redis.NewScript(`
+local coupon = redis.call('HMGET', KEYS[1], 'validFrom', 'validUntil', 'maxRedemptions', 'maxPerUser', 'redemptions')
+if not coupon[5] then
+	return 'not_found'
+end
+
+if redis.call('HEXISTS', KEYS[3], ARGV[2]) == 1 then
+	return 'ok'
+end
+
+local now = tonumber(ARGV[3])
+local validFrom, validUntil = tonumber(coupon[1]), tonumber(coupon[2])
+if (validFrom > 0 and now < validFrom) or (validUntil > 0 and now >= validUntil) then
+	return 'not_active'
+end
+
+local maxRedemptions = tonumber(coupon[3])
+if maxRedemptions > 0 and tonumber(coupon[5]) >= maxRedemptions then
+	return 'exhausted'
+end
+
+local maxPerUser = tonumber(coupon[4])
+if maxPerUser > 0 then
+	if ARGV[1] == '' then
+		return 'owner_required'
+	end
+	if tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0') >= maxPerUser then
+		return 'user_limit'
+	end
+end
+
+redis.call('HINCRBY', KEYS[1], 'redemptions', 1)
+redis.call('HINCRBY', KEYS[2], ARGV[1], 1)
+redis.call('HSET', KEYS[3], ARGV[2], ARGV[1])
+return 'ok'
+`)
+
+var redeemErrors = map[string]error{
+	"not_found":      ErrNotFound,
+	"not_active":     ErrNotActive,
+	"exhausted":      ErrExhausted,
+	"owner_required": ErrOwnerRequired,
+	"user_limit":     ErrUserLimit,
+}
+
+func (s *RedisStore) Redeem(ctx context.Context, code, userId string, basketId uuid.UUID, at time.Time) error {
+	result, err := redeemScript.Run(ctx, s.rdb, couponKeys(code), userId, basketId.String(), at.UnixMilli()).Text()
+	if err != nil {
+		return err
+	}
+
+	if result == "ok" {
+		return nil
+	}
+
+	if err, ok := redeemErrors[result]; ok {
+		return err
+	}
+
+	return fmt.Errorf("unexpected result redeeming coupon %s: %q", code, result)
+}
+
+var releaseScript = redis.NewScript(`
+local userId = redis.call('HGET', KEYS[3], ARGV[1])
+if not userId then
+	return 0
+end
+
+redis.call('HDEL', KEYS[3], ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'redemptions', -1)
+redis.call('HINCRBY', KEYS[2], userId, -1)
+return 1
+`)
+
+func (s *RedisStore) Release(ctx context.Context, code string, basketId uuid.UUID) error {
+	err := releaseScript.Run(ctx, s.rdb, couponKeys(code), basketId.String()).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+
+	return err
+}
+
+func toMillis(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
@@ -0,0 +1,1046 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"basket-service/auth"
+	"basket-service/coupons"
+	"basket-service/model"
+	"basket-service/outbox"
+	"basket-service/pricing"
+	"basket-service/products"
+	"basket-service/store"
+	"basket-service/telemetry"
+	"basket-service/validation"
+	"money-go"
+	"service-kit/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CreateBasketRequest struct {
+	Items  []CreateBasketItemRequest `json:"items"`
+	Region string                    `json:"region"`
+}
+
+func (r CreateBasketRequest) Validate() error {
+	return validateItems(r.Items)
+}
+
+type CreateBasketItemRequest struct {
+	ProductId string `json:"catalogId"`
+	Quantity  uint   `json:"quantity"`
+}
+
+type AddBasketItemsRequest struct {
+	Items []CreateBasketItemRequest `json:"items"`
+}
+
+func (r AddBasketItemsRequest) Validate() error {
+	return validateItems(r.Items)
+}
+
+type MergeBasketRequest struct {
+	BasketId string `json:"basketId"`
+}
+
+func (r MergeBasketRequest) Validate() error {
+	var errs validation.Errors
+	if r.BasketId == "" {
+		errs.Add("basketId", validation.CodeRequired, "Basket id is required")
+	} else if _, err := uuid.Parse(r.BasketId); err != nil {
+		errs.Add("basketId", validation.CodeInvalidUUID, "Basket id must be a valid UUID")
+	}
+
+	return errs.Err()
+}
+
+type UpdateBasketItemRequest struct {
+	Quantity uint `json:"quantity"`
+}
+
+func (r UpdateBasketItemRequest) Validate() error {
+	var errs validation.Errors
+	if r.Quantity < 1 {
+		errs.Add("quantity", validation.CodeMin, "Quantity must be at least 1")
+	}
+
+	return errs.Err()
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code"`
+}
+
+func (r ApplyCouponRequest) Validate() error {
+	var errs validation.Errors
+	if coupons.Normalize(r.Code) == "" {
+		errs.Add("code", validation.CodeRequired, "Coupon code is required")
+	}
+
+	return errs.Err()
+}
+
+type handler struct {
+	baskets  store.BasketStore
+	coupons  coupons.Store
+	products *products.Client
+	pricing  *pricing.Engine
+
+	// priceTolerance is how far, as a proportion of the price in the basket,
+	// a product's price may move before checkout asks the customer to confirm
+	// it. Zero means any change.
+	priceTolerance money.Rate
+
+	metrics *basketMetrics
+}
+
+// pricedBasket is a basket as clients and the orders queue see it, with its
+// price breakdown alongside.
+type pricedBasket struct {
+	*model.Basket
+	Pricing *pricing.Breakdown `json:"pricing"`
+}
+
+func (h *handler) price(basket *model.Basket) (pricedBasket, error) {
+	breakdown, err := h.pricing.Price(basket, basket.Coupons)
+	if err != nil {
+		return pricedBasket{}, err
+	}
+
+	return pricedBasket{Basket: basket, Pricing: breakdown}, nil
+}
+
+// send responds with the priced basket and its ETag.
+func (h *handler) send(c *fiber.Ctx, basket *model.Basket) error {
+	priced, err := h.price(basket)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not price basket"})
+	}
+
+	c.Set(fiber.HeaderETag, etag(basket))
+	return c.Status(fiber.StatusOK).JSON(priced)
+}
+
+// errorResponse rejects a request. Helpers shared between handlers return one
+// instead of writing the response themselves, and the handler passes it on to
+// respond.
+type errorResponse struct {
+	status int
+	body   fiber.Map
+}
+
+func (e *errorResponse) Error() string {
+	message, _ := e.body["message"].(string)
+	return message
+}
+
+func reject(status int, message string) *errorResponse {
+	return &errorResponse{status: status, body: fiber.Map{"message": message}}
+}
+
+// respond sends err to the client: problems and validation errors as
+// application/problem+json, an errorResponse as is and anything else as a 500.
+func respond(c *fiber.Ctx, err error) error {
+	var changed pricesChangedProblem
+	if errors.As(err, &changed) {
+		changed.Instance = c.OriginalURL()
+		return c.Status(changed.Status).JSON(changed, validation.ContentType)
+	}
+
+	var p validation.Problem
+	if errors.As(err, &p) {
+		return problem(c, p)
+	}
+
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		return problem(c, validation.ValidationProblem(errs))
+	}
+
+	var response *errorResponse
+	if !errors.As(err, &response) {
+		response = reject(fiber.StatusInternalServerError, "Something went wrong")
+	}
+
+	return c.Status(response.status).JSON(response.body)
+}
+
+func problem(c *fiber.Ctx, p validation.Problem) error {
+	p.Instance = c.OriginalURL()
+	return c.Status(p.Status).JSON(p, validation.ContentType)
+}
+
+// parseBody decodes the request body into request and validates it.
+func parseBody(c *fiber.Ctx, request interface{ Validate() error }) error {
+	if err := c.BodyParser(request); err != nil {
+		return validation.NewProblem(fiber.StatusBadRequest, err.Error())
+	}
+
+	return request.Validate()
+}
+
+func (h *handler) getBasket(c *fiber.Ctx) error {
+	basket, err := h.load(c)
+	if err != nil {
+		return respond(c, err)
+	}
+
+	return h.send(c, basket)
+}
+
+// getCurrentBasket responds with the signed in user's current basket.
+func (h *handler) getCurrentBasket(c *fiber.Ctx) error {
+	identity, ok := auth.FromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Sign in to see your basket"})
+	}
+
+	basket, err := h.current(c.UserContext(), identity.UserId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not find basket"})
+	}
+
+	if basket == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "No current basket"})
+	}
+
+	telemetry.LogBasket(c, basket.Id)
+
+	return h.send(c, basket)
+}
+
+// mergeBasket moves the items and coupons of the basket a customer filled
+// before signing in into their current basket, and deletes it. If they have no
+// current basket the anonymous one becomes theirs instead.
+func (h *handler) mergeBasket(c *fiber.Ctx) error {
+	identity, ok := auth.FromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Sign in to merge a basket"})
+	}
+
+	var request MergeBasketRequest
+	if err := parseBody(c, &request); err != nil {
+		return respond(c, err)
+	}
+
+	ctx := c.UserContext()
+
+	source, err := h.baskets.Get(ctx, uuid.MustParse(request.BasketId))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Record invalid"})
+	}
+
+	if source.OwnerId != "" && source.OwnerId != identity.UserId {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Basket belongs to another user"})
+	}
+	if !source.Status.Editable() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": statusMessage(source.Status)})
+	}
+
+	target, err := h.current(ctx, identity.UserId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not find basket"})
+	}
+
+	if target == nil || target.Id == source.Id {
+		source.OwnerId = identity.UserId
+		if err := resume(c, source); err != nil {
+			return respond(c, err)
+		}
+
+		if err := h.update(ctx, source); err != nil {
+			return respond(c, err)
+		}
+
+		return h.send(c, source)
+	}
+
+	if !target.Status.Editable() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": statusMessage(target.Status)})
+	}
+
+	// items the user already has keep the price they were added at
+	for _, item := range source.Items {
+		target.AddItem(item.ProductId, item.Price, item.Quantity)
+	}
+	for _, code := range source.Coupons {
+		if !slices.Contains(target.Coupons, code) {
+			target.Coupons = append(target.Coupons, code)
+		}
+	}
+
+	if err := resume(c, target); err != nil {
+		return respond(c, err)
+	}
+
+	if err := h.update(ctx, target); err != nil {
+		return respond(c, err)
+	}
+
+	// the anonymous basket has been emptied into the user's; if deleting it
+	// fails it is left to expire
+	if err := h.baskets.Delete(ctx, source.Id, source.Version); err != nil && !errors.Is(err, store.ErrNotFound) {
+		slog.ErrorContext(ctx, "could not delete merged basket",
+			slog.String("merged_basket_id", source.Id.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return h.send(c, target)
+}
+
+// current returns the user's most recently changed basket that is still in
+// use, or nil if they have none.
+func (h *handler) current(ctx context.Context, userId string) (*model.Basket, error) {
+	baskets, err := h.baskets.ListByOwner(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *model.Basket
+	for i := range baskets {
+		if baskets[i].Status.Final() {
+			continue
+		}
+
+		if current == nil || baskets[i].LastWritten().After(current.LastWritten()) {
+			current = &baskets[i]
+		}
+	}
+
+	return current, nil
+}
+
+func (h *handler) createBasket(c *fiber.Ctx) error {
+	var request CreateBasketRequest
+	if err := parseBody(c, &request); err != nil {
+		return respond(c, err)
+	}
+
+	if request.Region != "" && !h.pricing.HasRegion(request.Region) {
+		var errs validation.Errors
+		errs.Add("region", validation.CodeUnsupported, "Region is not supported")
+		return respond(c, errs)
+	}
+
+	basket := model.Basket{
+		Id:     uuid.New(),
+		Region: request.Region,
+	}
+
+	if identity, ok := auth.FromContext(c); ok {
+		basket.OwnerId = identity.UserId
+	}
+
+	if err := transition(&basket, model.StatusOpen, actor(c), "created"); err != nil {
+		return respond(c, err)
+	}
+
+	if err := h.addItems(c.UserContext(), &basket, request.Items); err != nil {
+		return respond(c, err)
+	}
+
+	telemetry.LogBasket(c, basket.Id)
+
+	if err := h.baskets.Create(c.UserContext(), &basket); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not save basket"})
+	}
+
+	h.metrics.basketCreated(c.UserContext(), &basket)
+
+	return h.send(c, &basket)
+}
+
+func (h *handler) addBasketItems(c *fiber.Ctx) error {
+	var request AddBasketItemsRequest
+	if err := parseBody(c, &request); err != nil {
+		return respond(c, err)
+	}
+
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		return h.addItems(ctx, basket, request.Items)
+	})
+}
+
+func (h *handler) updateBasketItem(c *fiber.Ctx) error {
+	itemId, err := uuid.Parse(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse item id to UUID"})
+	}
+
+	var request UpdateBasketItemRequest
+	if err := parseBody(c, &request); err != nil {
+		return respond(c, err)
+	}
+
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		item := basket.Item(itemId)
+		if item == nil {
+			return reject(fiber.StatusNotFound, "Item not found")
+		}
+
+		item.Quantity = request.Quantity
+		return nil
+	})
+}
+
+func (h *handler) removeBasketItem(c *fiber.Ctx) error {
+	itemId, err := uuid.Parse(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse item id to UUID"})
+	}
+
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		if !basket.RemoveItem(itemId) {
+			return reject(fiber.StatusNotFound, "Item not found")
+		}
+
+		return nil
+	})
+}
+
+func (h *handler) clearBasket(c *fiber.Ctx) error {
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		basket.Items = []model.BasketItem{}
+		return nil
+	})
+}
+
+func (h *handler) applyCoupon(c *fiber.Ctx) error {
+	var request ApplyCouponRequest
+	if err := parseBody(c, &request); err != nil {
+		return respond(c, err)
+	}
+
+	code := coupons.Normalize(request.Code)
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		if slices.Contains(basket.Coupons, code) {
+			return nil
+		}
+
+		if err := h.checkCoupon(ctx, basket, code); err != nil {
+			return err
+		}
+
+		basket.Coupons = append(basket.Coupons, code)
+		return nil
+	})
+}
+
+func (h *handler) removeCoupon(c *fiber.Ctx) error {
+	code := coupons.Normalize(c.Params("code"))
+	return h.mutate(c, func(ctx context.Context, basket *model.Basket) error {
+		i := slices.Index(basket.Coupons, code)
+		if i < 0 {
+			return reject(fiber.StatusNotFound, "Coupon not applied")
+		}
+
+		basket.Coupons = slices.Delete(basket.Coupons, i, i+1)
+		return nil
+	})
+}
+
+// checkCoupon reports whether code can be applied to the basket. Limits are
+// checked again when the coupon is redeemed at checkout, this only catches
+// coupons that could not be used as things stand.
+func (h *handler) checkCoupon(ctx context.Context, basket *model.Basket, code string) error {
+	invalid := func(code, message string) error {
+		var errs validation.Errors
+		errs.Add("code", code, message)
+		return errs
+	}
+
+	if !h.pricing.HasCoupon(code) {
+		return invalid(validation.CodeNotFound, "Coupon does not exist")
+	}
+
+	coupon, err := h.coupons.Get(ctx, code)
+	if errors.Is(err, coupons.ErrNotFound) {
+		return invalid(validation.CodeNotFound, "Coupon does not exist")
+	}
+	if err != nil {
+		return reject(fiber.StatusInternalServerError, "Could not check coupon")
+	}
+
+	if err := coupon.Check(time.Now()); err != nil {
+		message, _ := couponMessage(err)
+		return invalid(validation.CodeUnavailable, message)
+	}
+
+	if coupon.MaxPerUser > 0 && basket.OwnerId == "" {
+		message, _ := couponMessage(coupons.ErrOwnerRequired)
+		return invalid(validation.CodeUnavailable, message)
+	}
+
+	return nil
+}
+
+// redeemCoupons redeems every coupon on the basket, or none of them. The
+// redemptions are returned so they can be released if checkout fails later.
+func (h *handler) redeemCoupons(ctx context.Context, basket *model.Basket) ([]string, error) {
+	var redeemed []string
+	for _, code := range basket.Coupons {
+		err := h.coupons.Redeem(ctx, code, basket.OwnerId, basket.Id, time.Now())
+		if err != nil {
+			h.releaseCoupons(ctx, basket.Id, redeemed)
+
+			message, ok := couponMessage(err)
+			if !ok {
+				return nil, reject(fiber.StatusInternalServerError, "Could not redeem coupon")
+			}
+			return nil, validation.NewProblem(fiber.StatusConflict, fmt.Sprintf("%s: %s", code, message))
+		}
+
+		redeemed = append(redeemed, code)
+	}
+
+	return redeemed, nil
+}
+
+func (h *handler) releaseCoupons(ctx context.Context, basketId uuid.UUID, codes []string) {
+	for _, code := range codes {
+		if err := h.coupons.Release(ctx, code, basketId); err != nil {
+			slog.ErrorContext(ctx, "Could not release coupon", "coupon", code, logging.BasketIDKey, basketId, "error", err)
+		}
+	}
+}
+
+// couponMessage explains why a coupon was turned down, or reports false if err
+// is not one of the coupons package's reasons.
+func couponMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, coupons.ErrNotFound):
+		return "Coupon does not exist", true
+	case errors.Is(err, coupons.ErrNotActive):
+		return "Coupon is not active", true
+	case errors.Is(err, coupons.ErrExhausted):
+		return "Coupon has been used up", true
+	case errors.Is(err, coupons.ErrUserLimit):
+		return "Coupon has already been used", true
+	case errors.Is(err, coupons.ErrOwnerRequired):
+		return "Sign in to use this coupon", true
+	default:
+		return "", false
+	}
+}
+
+func (h *handler) deleteBasket(c *fiber.Ctx) error {
+	basket, err := h.load(c)
+	if err != nil {
+		return respond(c, err)
+	}
+
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch != "" && !etagMatches(ifMatch, basket) {
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"message": "Basket has been modified"})
+	}
+
+	if !basket.Status.Deletable() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": statusMessage(basket.Status)})
+	}
+
+	// the version checked against If-Match is the one deleted, so a write in
+	// between is not thrown away
+	err = h.baskets.Delete(c.UserContext(), basket.Id, basket.Version)
+	if errors.Is(err, store.ErrConflict) {
+		if ifMatch != "" {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"message": "Basket has been modified"})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": "Basket was modified concurrently, try again"})
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not delete basket"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *handler) checkout(c *fiber.Ctx) error {
+	order, err := h.placeOrder(c)
+	h.metrics.checkedOut(c.UserContext(), order, err)
+	if err != nil {
+		return respond(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, etag(order.Basket))
+	return c.Status(fiber.StatusAccepted).JSON(order)
+}
+
+// placeOrder checks out the basket, writing the order for the outbox relay to
+// publish.
+func (h *handler) placeOrder(c *fiber.Ctx) (pricedBasket, error) {
+	basket, err := h.load(c)
+	if err != nil {
+		return pricedBasket{}, err
+	}
+
+	ctx := c.UserContext()
+
+	if err := resume(c, basket); err != nil {
+		return pricedBasket{}, err
+	}
+
+	// checking_out is saved first so the basket cannot be changed while its
+	// prices are checked and its coupons redeemed
+	if err := transition(basket, model.StatusCheckingOut, actor(c), "checkout started"); err != nil {
+		return pricedBasket{}, err
+	}
+
+	err = h.baskets.Update(ctx, basket)
+	if errors.Is(err, store.ErrConflict) {
+		return pricedBasket{}, reject(fiber.StatusConflict, "Basket was modified during checkout, try again")
+	}
+	if err != nil {
+		return pricedBasket{}, reject(fiber.StatusInternalServerError, "Unable to checkout basket")
+	}
+
+	// from here on a failed checkout puts the basket back to open, so the
+	// customer can fix whatever stopped it and try again
+	fail := func(err error) (pricedBasket, error) {
+		h.abortCheckout(context.WithoutCancel(ctx), basket.Id, err)
+		return pricedBasket{}, err
+	}
+
+	if err := h.checkPrices(ctx, basket); err != nil {
+		return fail(err)
+	}
+
+	if err := transition(basket, model.StatusCheckedOut, actor(c), "order requested"); err != nil {
+		return fail(err)
+	}
+
+	// the order carries the prices the customer was shown, so the order
+	// service never has to work them out again
+	order, err := h.price(basket)
+	if err != nil {
+		return fail(reject(fiber.StatusInternalServerError, "Could not price basket"))
+	}
+
+	// the order is published by the outbox relay, which keeps retrying until
+	// the broker confirms it
+	event, err := outbox.NewEvent(ctx, orderRequestedEvent, "", ordersQueue, order)
+	if err != nil {
+		return fail(reject(fiber.StatusInternalServerError, "Unable to checkout basket"))
+	}
+
+	// a basket is only ordered once, so the message id is derived from it and
+	// the order service can drop any duplicate delivery
+	event.Id = outbox.EventId(orderRequestedEvent, basket.Id)
+
+	// coupons are redeemed before the order is written so that one can never
+	// be ordered past its limits; if writing the order fails they are given
+	// back
+	redeemed, err := h.redeemCoupons(ctx, basket)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = h.baskets.UpdateWithEvent(ctx, basket, event)
+	if err != nil {
+		h.releaseCoupons(context.WithoutCancel(ctx), basket.Id, redeemed)
+	}
+	if errors.Is(err, store.ErrConflict) {
+		return fail(reject(fiber.StatusConflict, "Basket was modified during checkout, try again"))
+	}
+	if err != nil {
+		return fail(reject(fiber.StatusInternalServerError, "Unable to checkout basket"))
+	}
+
+	return order, nil
+}
+
+// abortCheckout moves a basket left in checking_out by a failed checkout back
+// to open, recording why. It reads the basket afresh, so it only undoes what
+// was saved, and leaves alone a basket that has already been reopened.
+func (h *handler) abortCheckout(ctx context.Context, id uuid.UUID, cause error) {
+	basket, err := h.baskets.Get(ctx, id)
+	if err == nil && basket.Status != model.StatusCheckingOut {
+		return
+	}
+	if err == nil {
+		err = transition(basket, model.StatusOpen, model.ActorSystem, "checkout failed: "+cause.Error())
+	}
+	if err == nil {
+		err = h.baskets.Update(ctx, basket)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not reopen basket after failed checkout",
+			slog.String(logging.BasketIDKey, id.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// actor names who a request is made by, for the basket's history: the signed
+// in user, or "anonymous".
+func actor(c *fiber.Ctx) string {
+	if identity, ok := auth.FromContext(c); ok {
+		return "user:" + identity.UserId
+	}
+
+	return "anonymous"
+}
+
+// transition moves the basket to status to, or rejects the request with a 409
+// if its current status does not allow that.
+func transition(basket *model.Basket, to model.Status, actor, reason string) error {
+	err := basket.Transition(to, actor, reason, time.Now())
+
+	var transitionErr *model.TransitionError
+	if errors.As(err, &transitionErr) {
+		return reject(fiber.StatusConflict, statusMessage(basket.Status))
+	}
+
+	return err
+}
+
+// resume reopens an abandoned basket whose customer has come back to it.
+func resume(c *fiber.Ctx, basket *model.Basket) error {
+	if basket.Status != model.StatusAbandoned {
+		return nil
+	}
+
+	return transition(basket, model.StatusOpen, actor(c), "customer returned")
+}
+
+// statusMessage explains why a basket in status cannot be changed or checked
+// out.
+func statusMessage(status model.Status) string {
+	switch status {
+	case model.StatusCheckingOut:
+		return "Basket is being checked out"
+	case model.StatusCheckedOut:
+		return "Basket has already been checked out"
+	case model.StatusExpired:
+		return "Basket has expired"
+	default:
+		return fmt.Sprintf("Basket is %s", status)
+	}
+}
+
+// priceChange is how an item's product has changed since it was added to the
+// basket. CurrentPrice is missing when the product is no longer available.
+type priceChange struct {
+	ItemId       uuid.UUID    `json:"itemId"`
+	ProductId    uuid.UUID    `json:"catalogId"`
+	Price        money.Money  `json:"price"`
+	CurrentPrice *money.Money `json:"currentPrice,omitempty"`
+	Available    bool         `json:"available"`
+}
+
+// pricesChangedProblem is a 409 problem listing the items whose products have
+// changed.
+type pricesChangedProblem struct {
+	validation.Problem
+	Items []priceChange `json:"items"`
+}
+
+// checkPrices compares the basket with product-service before it is checked
+// out. If a price has moved by more than the tolerance the basket is reopened
+// and saved at the current price, so checking out again confirms it, and the
+// changes are returned as a pricesChangedProblem. Products that are no longer available
+// are reported too, and have to be removed before checking out.
+func (h *handler) checkPrices(ctx context.Context, basket *model.Basket) error {
+	if len(basket.Items) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(basket.Items))
+	for i, item := range basket.Items {
+		ids[i] = item.ProductId
+	}
+
+	current, err := h.products.Refresh(ctx, ids)
+
+	var missing *products.MissingError
+	if errors.Is(err, products.ErrCircuitOpen) {
+		return reject(fiber.StatusServiceUnavailable, "Product service is unavailable")
+	}
+	if err != nil && !errors.As(err, &missing) {
+		return reject(fiber.StatusInternalServerError, "Could not check prices")
+	}
+
+	var changes []priceChange
+	for i := range basket.Items {
+		item := &basket.Items[i]
+
+		product, ok := current[item.ProductId]
+		if !ok {
+			changes = append(changes, priceChange{ItemId: item.Id, ProductId: item.ProductId, Price: item.Price})
+			continue
+		}
+
+		drifted, err := h.drifted(item.Price, product.Price)
+		if err != nil {
+			return reject(fiber.StatusInternalServerError, "Could not check prices")
+		}
+
+		if drifted {
+			changes = append(changes, priceChange{
+				ItemId:       item.Id,
+				ProductId:    item.ProductId,
+				Price:        item.Price,
+				CurrentPrice: &product.Price,
+				Available:    true,
+			})
+			item.Price = product.Price
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := transition(basket, model.StatusOpen, model.ActorSystem, "prices changed"); err != nil {
+		return err
+	}
+
+	err = h.baskets.Update(ctx, basket)
+	if errors.Is(err, store.ErrConflict) {
+		return reject(fiber.StatusConflict, "Basket was modified during checkout, try again")
+	}
+	if err != nil {
+		return reject(fiber.StatusInternalServerError, "Could not save basket")
+	}
+
+	return pricesChangedProblem{
+		Problem: validation.Problem{
+			Type:   validation.TypePricesChanged,
+			Title:  "Prices have changed",
+			Status: fiber.StatusConflict,
+			Detail: "Some items have changed price or are no longer available since they were added. Review them and check out again to confirm.",
+		},
+		Items: changes,
+	}
+}
+
+// drifted reports whether current differs from price by more than the
+// handler's tolerance.
+func (h *handler) drifted(price, current money.Money) (bool, error) {
+	diff, err := current.Sub(price)
+	if err != nil {
+		return false, err
+	}
+
+	if diff.IsNegative() {
+		if diff, err = price.Sub(current); err != nil {
+			return false, err
+		}
+	}
+
+	allowed, err := price.MulRate(h.priceTolerance)
+	if err != nil {
+		return false, err
+	}
+
+	c, err := diff.Cmp(allowed)
+	return c > 0, err
+}
+
+// load reads the basket named by the :id param, if the request may use it.
+// Anyone with its id may use an anonymous basket, but one with an owner is
+// only for them.
+func (h *handler) load(c *fiber.Ctx) (*model.Basket, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, reject(fiber.StatusBadRequest, "Could not parse id to UUID")
+	}
+
+	telemetry.LogBasket(c, id)
+
+	basket, err := h.baskets.Get(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, reject(fiber.StatusNotFound, "Record not found")
+	}
+	if err != nil {
+		return nil, reject(fiber.StatusInternalServerError, "Record invalid")
+	}
+
+	if basket.OwnerId == "" {
+		return basket, nil
+	}
+
+	identity, ok := auth.FromContext(c)
+	if !ok {
+		return nil, reject(fiber.StatusUnauthorized, "Sign in to use this basket")
+	}
+	if identity.UserId != basket.OwnerId {
+		return nil, reject(fiber.StatusForbidden, "Basket belongs to another user")
+	}
+
+	return basket, nil
+}
+
+// update saves the basket, rejecting the request if it was written by someone
+// else in the meantime.
+func (h *handler) update(ctx context.Context, basket *model.Basket) error {
+	err := h.baskets.Update(ctx, basket)
+	if errors.Is(err, store.ErrConflict) {
+		return reject(fiber.StatusConflict, "Basket was modified concurrently, try again")
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return reject(fiber.StatusNotFound, "Record not found")
+	}
+	if err != nil {
+		return reject(fiber.StatusInternalServerError, "Could not save basket")
+	}
+
+	return nil
+}
+
+// mutate loads the basket named by the :id param, applies fn to it and saves
+// it, responding with the updated basket. Saving restarts the basket's time to
+// live. If the request has an If-Match header the change is only made if it
+// matches the basket's current ETag. Only an open or abandoned basket can be
+// changed, and changing an abandoned one reopens it.
+func (h *handler) mutate(c *fiber.Ctx, fn func(ctx context.Context, basket *model.Basket) error) error {
+	basket, err := h.load(c)
+	if err != nil {
+		return respond(c, err)
+	}
+
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch != "" && !etagMatches(ifMatch, basket) {
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"message": "Basket has been modified"})
+	}
+
+	if !basket.Status.Editable() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": statusMessage(basket.Status)})
+	}
+
+	if err := resume(c, basket); err != nil {
+		return respond(c, err)
+	}
+
+	if err := fn(c.UserContext(), basket); err != nil {
+		return respond(c, err)
+	}
+
+	err = h.baskets.Update(c.UserContext(), basket)
+	if errors.Is(err, store.ErrConflict) {
+		// someone else wrote the basket between our read and write; without
+		// If-Match the client did not ask for a precondition, so it is told to
+		// retry rather than that its precondition failed
+		if ifMatch != "" {
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"message": "Basket has been modified"})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": "Basket was modified concurrently, try again"})
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not save basket"})
+	}
+
+	return h.send(c, basket)
+}
+
+func validateItems(items []CreateBasketItemRequest) error {
+	var errs validation.Errors
+	if len(items) == 0 {
+		errs.Add("items", validation.CodeRequired, "At least one item is required")
+	}
+
+	for i, item := range items {
+		path := validation.Index("items", i)
+
+		if item.ProductId == "" {
+			errs.Add(validation.Field(path, "catalogId"), validation.CodeRequired, "Product id is required")
+		} else if _, err := uuid.Parse(item.ProductId); err != nil {
+			errs.Add(validation.Field(path, "catalogId"), validation.CodeInvalidUUID, "Product id must be a valid UUID")
+		}
+
+		if item.Quantity < 1 {
+			errs.Add(validation.Field(path, "quantity"), validation.CodeMin, "Quantity must be at least 1")
+		}
+	}
+
+	return errs.Err()
+}
+
+// addItems adds validated items to the basket, merging them with any item for
+// the same product. Only products new to the basket are looked up, existing
+// items keep the price they were added at.
+func (h *handler) addItems(ctx context.Context, basket *model.Basket, items []CreateBasketItemRequest) error {
+	var ids []uuid.UUID
+	for _, item := range items {
+		id := uuid.MustParse(item.ProductId)
+		if !hasProduct(basket, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	found := map[uuid.UUID]products.Product{}
+	if len(ids) > 0 {
+		var err error
+		found, err = h.products.GetMany(ctx, ids)
+
+		var missing *products.MissingError
+		if errors.As(err, &missing) {
+			var errs validation.Errors
+			for i, item := range items {
+				if slices.Contains(missing.Ids, uuid.MustParse(item.ProductId)) {
+					errs.Add(validation.Field(validation.Index("items", i), "catalogId"), validation.CodeNotFound, "Product does not exist")
+				}
+			}
+			return errs
+		}
+		if errors.Is(err, products.ErrCircuitOpen) {
+			return reject(fiber.StatusServiceUnavailable, "Product service is unavailable")
+		}
+		if err != nil {
+			return reject(fiber.StatusInternalServerError, "Could not validate product")
+		}
+	}
+
+	for _, item := range items {
+		id := uuid.MustParse(item.ProductId)
+		basket.AddItem(id, found[id].Price, item.Quantity)
+	}
+
+	return nil
+}
+
+func hasProduct(basket *model.Basket, productId uuid.UUID) bool {
+	for _, item := range basket.Items {
+		if item.ProductId == productId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etag is the basket's strong entity tag, its store version in quotes.
+func etag(basket *model.Basket) string {
+	return strconv.Quote(strconv.FormatUint(basket.Version, 10))
+}
+
+// etagMatches reports whether an If-Match header matches the basket.
+func etagMatches(ifMatch string, basket *model.Basket) bool {
+	current := etag(basket)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"basket-service/auth"
+	"basket-service/coupons"
+	"basket-service/idempotency"
+	"basket-service/model"
+	"basket-service/pricing"
+	"basket-service/products"
+	"basket-service/store"
+	"basket-service/validation"
+	"money-go"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("test-secret")
+
+// productService is an httptest stand-in for product-service. It only has
+// single lookups, so the client falls back to them from the batch endpoint.
+type productService struct {
+	mu     sync.Mutex
+	prices map[uuid.UUID]string
+}
+
+func (p *productService) set(id uuid.UUID, price string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prices[id] = price
+}
+
+func (p *productService) remove(id uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.prices, id)
+}
+
+func (p *productService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(r.URL.Path, "/api/products/"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	price, ok := p.prices[id]
+	p.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	fmt.Fprintf(w, `{"id":%q,"name":"Product","price":%s}`, id, price)
+}
+
+type testService struct {
+	app      *fiber.App
+	baskets  *store.MemoryStore
+	products *productService
+}
+
+// newTestService serves the basket API like main does, against the memory
+// stores and a stand-in product-service.
+func newTestService(t *testing.T, tolerance string) *testService {
+	catalog := &productService{prices: map[uuid.UUID]string{}}
+	server := httptest.NewServer(catalog)
+	t.Cleanup(server.Close)
+
+	engine, err := pricing.New(model.Currency, pricing.DefaultConfig(model.Currency))
+	require.NoError(t, err)
+
+	metrics, err := newBasketMetrics()
+	require.NoError(t, err)
+
+	baskets := store.NewMemoryStore(time.Hour)
+	h := &handler{
+		baskets:        baskets,
+		coupons:        coupons.NewMemoryStore(),
+		pricing:        engine,
+		products:       products.New(server.URL, server.Client(), products.Options{Currency: model.Currency}),
+		priceTolerance: money.MustParseRate(tolerance),
+		metrics:        metrics,
+	}
+
+	app := fiber.New()
+	app.Use(auth.Middleware(auth.NewVerifier(testSecret)))
+	h.routes(app, idempotency.NewMemoryStore(time.Hour))
+
+	return &testService{app: app, baskets: baskets, products: catalog}
+}
+
+// product adds a product at price to product-service.
+func (s *testService) product(price string) uuid.UUID {
+	id := uuid.New()
+	s.products.set(id, price)
+	return id
+}
+
+// request sends a request as userId, or anonymously if it is empty. The
+// headers are given as name, value pairs.
+func (s *testService) request(t *testing.T, method, path, userId, body string, headers ...string) *http.Response {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if userId != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token(t, userId))
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	res, err := s.app.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+// create creates a basket for userId holding one of each product.
+func (s *testService) create(t *testing.T, userId string, ids ...uuid.UUID) model.Basket {
+	res := s.request(t, http.MethodPost, "/api/basket", userId, itemsBody(ids...))
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	return decode[model.Basket](t, res)
+}
+
+func token(t *testing.T, userId string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": auth.Issuer,
+		"sub": userId,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	require.NoError(t, err)
+
+	return token
+}
+
+func itemsBody(ids ...uuid.UUID) string {
+	items := make([]string, len(ids))
+	for i, id := range ids {
+		items[i] = fmt.Sprintf(`{"catalogId":%q,"quantity":1}`, id)
+	}
+
+	return `{"items":[` + strings.Join(items, ",") + `]}`
+}
+
+func decode[T any](t *testing.T, res *http.Response) T {
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	var v T
+	require.NoError(t, json.Unmarshal(body, &v), string(body))
+
+	return v
+}
+
+func Test_Handler_IfMatch(t *testing.T) {
+	s := newTestService(t, "0")
+	productId := s.product("10.00")
+	basket := s.create(t, "", productId)
+	path := "/api/basket/" + basket.Id.String()
+
+	res := s.request(t, http.MethodDelete, path+"/items", "", "", fiber.HeaderIfMatch, `"99"`)
+	require.Equal(t, fiber.StatusPreconditionFailed, res.StatusCode)
+
+	res = s.request(t, http.MethodDelete, path+"/items", "", "", fiber.HeaderIfMatch, `"99", `+etag(&basket))
+	require.Equal(t, fiber.StatusOK, res.StatusCode, "any listed tag may match")
+	cleared := decode[model.Basket](t, res)
+	require.Empty(t, cleared.Items)
+	require.Equal(t, etag(&cleared), res.Header.Get(fiber.HeaderETag))
+
+	res = s.request(t, http.MethodPatch, path+"/items", "", itemsBody(productId), fiber.HeaderIfMatch, etag(&basket))
+	require.Equal(t, fiber.StatusPreconditionFailed, res.StatusCode, "the basket has moved on from the tag")
+
+	res = s.request(t, http.MethodPatch, path+"/items", "", itemsBody(productId), fiber.HeaderIfMatch, "*")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res = s.request(t, http.MethodDelete, path, "", "", fiber.HeaderIfMatch, etag(&cleared))
+	require.Equal(t, fiber.StatusPreconditionFailed, res.StatusCode)
+
+	current, err := s.baskets.Get(context.Background(), basket.Id)
+	require.NoError(t, err)
+
+	res = s.request(t, http.MethodDelete, path, "", "", fiber.HeaderIfMatch, etag(current))
+	require.Equal(t, fiber.StatusNoContent, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, path, "", "")
+	require.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
+
+func Test_Handler_ValidationProblem(t *testing.T) {
+	s := newTestService(t, "0")
+
+	res := s.request(t, http.MethodPost, "/api/basket", "", `{"items":[{"catalogId":"nope","quantity":0},{"quantity":1}]}`)
+	require.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	require.Equal(t, validation.ContentType, res.Header.Get(fiber.HeaderContentType))
+
+	problem := decode[validation.Problem](t, res)
+	require.Equal(t, validation.TypeValidation, problem.Type)
+	require.Equal(t, "/api/basket", problem.Instance)
+	require.Len(t, problem.Errors, 3, "every invalid field is listed")
+	require.Equal(t, "items[0].catalogId", problem.Errors[0].Path)
+	require.Equal(t, validation.CodeInvalidUUID, problem.Errors[0].Code)
+	require.Equal(t, "items[0].quantity", problem.Errors[1].Path)
+	require.Equal(t, validation.CodeMin, problem.Errors[1].Code)
+	require.Equal(t, "items[1].catalogId", problem.Errors[2].Path)
+	require.Equal(t, validation.CodeRequired, problem.Errors[2].Code)
+
+	res = s.request(t, http.MethodPost, "/api/basket", "", itemsBody(s.product("10.00"), uuid.New()))
+	require.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+	problem = decode[validation.Problem](t, res)
+	require.Len(t, problem.Errors, 1)
+	require.Equal(t, "items[1].catalogId", problem.Errors[0].Path)
+	require.Equal(t, validation.CodeNotFound, problem.Errors[0].Code)
+}
+
+func Test_Handler_Delete_CheckedOut(t *testing.T) {
+	s := newTestService(t, "0")
+	basket := s.create(t, "", s.product("10.00"))
+	path := "/api/basket/" + basket.Id.String()
+
+	res := s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode)
+
+	res = s.request(t, http.MethodDelete, path, "", "")
+	require.Equal(t, fiber.StatusConflict, res.StatusCode)
+	require.Equal(t, "Basket has already been checked out", decode[fiber.Map](t, res)["message"])
+
+	_, err := s.baskets.Get(context.Background(), basket.Id)
+	require.NoError(t, err)
+}
+
+func Test_Handler_Checkout_Idempotent(t *testing.T) {
+	s := newTestService(t, "0")
+	basket := s.create(t, "", s.product("10.00"))
+	path := "/api/basket/" + basket.Id.String()
+
+	res := s.request(t, http.MethodPost, path+"/checkout", "", "", idempotency.Header, "checkout-1")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode)
+	require.Empty(t, res.Header.Get(idempotency.ReplayedHeader))
+	order := decode[fiber.Map](t, res)
+
+	res = s.request(t, http.MethodPost, path+"/checkout", "", "", idempotency.Header, "checkout-1")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode, "a retry gets the first response")
+	require.Equal(t, "true", res.Header.Get(idempotency.ReplayedHeader))
+	require.Equal(t, order, decode[fiber.Map](t, res))
+
+	res = s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusConflict, res.StatusCode, "without the key the checkout runs again")
+
+	other := s.create(t, "", s.product("10.00"))
+	res = s.request(t, http.MethodPost, "/api/basket/"+other.Id.String()+"/checkout", "", "", idempotency.Header, "checkout-1")
+	require.Equal(t, fiber.StatusUnprocessableEntity, res.StatusCode, "a key belongs to the request it was first sent with")
+}
+
+func Test_Handler_Checkout_PricesChanged(t *testing.T) {
+	s := newTestService(t, "5%")
+	steady := s.product("10.00")
+	nudged := s.product("20.00")
+	raised := s.product("30.00")
+	basket := s.create(t, "", steady, nudged, raised)
+	path := "/api/basket/" + basket.Id.String()
+
+	// 20.00 to 21.00 is within 5%, 30.00 to 33.00 is not
+	s.products.set(nudged, "21.00")
+	s.products.set(raised, "33.00")
+
+	res := s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusConflict, res.StatusCode)
+	require.Equal(t, validation.ContentType, res.Header.Get(fiber.HeaderContentType))
+
+	problem := decode[pricesChangedProblem](t, res)
+	require.Equal(t, validation.TypePricesChanged, problem.Type)
+	require.Equal(t, path+"/checkout", problem.Instance)
+	require.Len(t, problem.Items, 1)
+	require.Equal(t, raised, problem.Items[0].ProductId)
+	require.Equal(t, money.MustParse("30.00", model.Currency), problem.Items[0].Price)
+	require.Equal(t, money.MustParse("33.00", model.Currency), *problem.Items[0].CurrentPrice)
+	require.True(t, problem.Items[0].Available)
+
+	reopened, err := s.baskets.Get(context.Background(), basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusOpen, reopened.Status)
+	for _, item := range reopened.Items {
+		if item.ProductId == raised {
+			require.Equal(t, money.MustParse("33.00", model.Currency), item.Price, "the basket is saved at the current price")
+		}
+		if item.ProductId == nudged {
+			require.Equal(t, money.MustParse("20.00", model.Currency), item.Price, "a change within the tolerance keeps the price")
+		}
+	}
+
+	res = s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode, "checking out again confirms the new price")
+}
+
+func Test_Handler_Checkout_Unavailable(t *testing.T) {
+	s := newTestService(t, "0")
+	kept := s.product("10.00")
+	gone := s.product("20.00")
+	basket := s.create(t, "", kept, gone)
+	path := "/api/basket/" + basket.Id.String()
+
+	s.products.remove(gone)
+
+	res := s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusConflict, res.StatusCode)
+
+	problem := decode[pricesChangedProblem](t, res)
+	require.Len(t, problem.Items, 1)
+	require.Equal(t, gone, problem.Items[0].ProductId)
+	require.False(t, problem.Items[0].Available)
+	require.Nil(t, problem.Items[0].CurrentPrice)
+
+	res = s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusConflict, res.StatusCode, "the product has to be removed first")
+
+	reopened, err := s.baskets.Get(context.Background(), basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusOpen, reopened.Status)
+
+	var itemId uuid.UUID
+	for _, item := range reopened.Items {
+		if item.ProductId == gone {
+			itemId = item.Id
+		}
+	}
+
+	res = s.request(t, http.MethodDelete, path+"/items/"+itemId.String(), "", "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res = s.request(t, http.MethodPost, path+"/checkout", "", "")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode)
+}
+
+func Test_Handler_Owner(t *testing.T) {
+	s := newTestService(t, "0")
+	basket := s.create(t, "alice", s.product("10.00"))
+	require.Equal(t, "alice", basket.OwnerId)
+	path := "/api/basket/" + basket.Id.String()
+
+	res := s.request(t, http.MethodGet, path, "", "")
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, path, "bob", "")
+	require.Equal(t, fiber.StatusForbidden, res.StatusCode)
+
+	res = s.request(t, http.MethodDelete, path+"/items", "bob", "")
+	require.Equal(t, fiber.StatusForbidden, res.StatusCode)
+
+	res = s.request(t, http.MethodDelete, path, "", "")
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, path, "alice", "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, path, "", "", fiber.HeaderAuthorization, "Bearer not-a-token")
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+}
+
+func Test_Handler_GetCurrentBasket(t *testing.T) {
+	s := newTestService(t, "0")
+	productId := s.product("10.00")
+
+	res := s.request(t, http.MethodGet, "/api/basket/me", "", "")
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, "/api/basket/me", "alice", "")
+	require.Equal(t, fiber.StatusNotFound, res.StatusCode)
+
+	basket := s.create(t, "alice", productId)
+	s.create(t, "bob", productId)
+
+	res = s.request(t, http.MethodGet, "/api/basket/me", "alice", "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	require.Equal(t, basket.Id, decode[model.Basket](t, res).Id)
+
+	res = s.request(t, http.MethodPost, "/api/basket/"+basket.Id.String()+"/checkout", "alice", "")
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode)
+
+	res = s.request(t, http.MethodGet, "/api/basket/me", "alice", "")
+	require.Equal(t, fiber.StatusNotFound, res.StatusCode, "a checked out basket is no longer current")
+}
+
+func Test_Handler_MergeBasket(t *testing.T) {
+	s := newTestService(t, "0")
+	mine := s.product("10.00")
+	theirs := s.product("20.00")
+
+	target := s.create(t, "alice", mine)
+	source := s.create(t, "", mine, theirs)
+	body := fmt.Sprintf(`{"basketId":%q}`, source.Id)
+
+	res := s.request(t, http.MethodPost, "/api/basket/me/merge", "", body)
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+
+	bobs := s.create(t, "bob", theirs)
+	res = s.request(t, http.MethodPost, "/api/basket/me/merge", "alice", fmt.Sprintf(`{"basketId":%q}`, bobs.Id))
+	require.Equal(t, fiber.StatusForbidden, res.StatusCode)
+
+	res = s.request(t, http.MethodPost, "/api/basket/me/merge", "alice", body)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	merged := decode[model.Basket](t, res)
+	require.Equal(t, target.Id, merged.Id)
+	require.Len(t, merged.Items, 2)
+	for _, item := range merged.Items {
+		if item.ProductId == mine {
+			require.Equal(t, uint(2), item.Quantity)
+		}
+	}
+
+	_, err := s.baskets.Get(context.Background(), source.Id)
+	require.ErrorIs(t, err, store.ErrNotFound, "the merged basket is deleted")
+}
+
+func Test_Handler_MergeBasket_NoCurrentBasket(t *testing.T) {
+	s := newTestService(t, "0")
+	source := s.create(t, "", s.product("10.00"))
+
+	res := s.request(t, http.MethodPost, "/api/basket/me/merge", "alice", fmt.Sprintf(`{"basketId":%q}`, source.Id))
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	claimed := decode[model.Basket](t, res)
+	require.Equal(t, source.Id, claimed.Id, "the anonymous basket becomes theirs")
+	require.Equal(t, "alice", claimed.OwnerId)
+
+	res = s.request(t, http.MethodGet, "/api/basket/"+source.Id.String(), "", "")
+	require.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+}
@@ -0,0 +1,219 @@
+// Package idempotency makes unsafe requests safe to retry. A client sends an
+// Idempotency-Key header with a request; the first request with that key runs
+// and its response is kept, and any retry with the same key gets the kept
+// response back instead of running again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"basket-service/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Header is the request header carrying the key.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// maxKeyLength bounds keys so they cannot be used to fill the store.
+	maxKeyLength = 255
+
+	// lockTTL is how long a key is held while its request runs. It only
+	// matters if the service dies mid-request, and should be longer than any
+	// request takes.
+	lockTTL = time.Minute
+)
+
+var (
+	// ErrInProgress is returned by Store.Begin while another request holds
+	// the key.
+	ErrInProgress = errors.New("a request with this key is in progress")
+
+	// ErrMismatch is returned by Store.Begin when the key was first used for a
+	// different request.
+	ErrMismatch = errors.New("key was used for a different request")
+)
+
+// Response is what is kept of a response to replay it.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+// Store keeps keys and their responses until they expire.
+//
+// A key goes through two states. Begin claims it for a request, identified by
+// a fingerprint, for a short time; Complete then keeps the response for the
+// store's TTL, or Release gives the key up so the request can be retried.
+type Store interface {
+	// Begin claims key for the request with the given fingerprint. If the key
+	// has already completed its response is returned for replaying instead.
+	// It returns ErrInProgress if the key is claimed and not yet complete,
+	// and ErrMismatch if it was claimed with a different fingerprint.
+	Begin(ctx context.Context, key, fingerprint string) (*Response, error)
+
+	// Complete keeps response as the answer to every request with key.
+	Complete(ctx context.Context, key string, response Response) error
+
+	// Release forgets key.
+	Release(ctx context.Context, key string) error
+}
+
+// record is what a Store keeps for each key. Response is nil until the key's
+// request has completed.
+type record struct {
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response,omitempty"`
+}
+
+// begin decides what Begin returns for a key that is already held.
+func (r record) begin(fingerprint string) (*Response, error) {
+	if r.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+
+	if r.Response == nil {
+		return nil, ErrInProgress
+	}
+
+	return r.Response, nil
+}
+
+// Middleware runs each request with an Idempotency-Key header at most once,
+// replaying its response to any retry. Requests without the header run as
+// normal.
+//
+// A key is tied to the request it was first sent with: reusing it for another
+// method, path, user or body is rejected with a 422, and a retry while the first
+// request is still running with a 409. Only responses that settled the
+// request are kept. A 5xx, 409 or 429 depends on something that may change,
+// so the key is released and the request can be retried with it.
+func Middleware(s Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(Header)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > maxKeyLength {
+			return problem(c, fiber.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		}
+
+		ctx := c.UserContext()
+
+		response, err := s.Begin(ctx, key, fingerprint(c))
+		if errors.Is(err, ErrInProgress) {
+			return problem(c, fiber.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		}
+		if errors.Is(err, ErrMismatch) {
+			return problem(c, fiber.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request")
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not check Idempotency-Key"})
+		}
+
+		if response != nil {
+			return replay(c, response)
+		}
+
+		// run the error handler now so the kept response is the one sent
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// the response has been written, so keeping it must not be cut short
+		// by the client going away
+		ctx = context.WithoutCancel(ctx)
+
+		// nor can failing to keep it change the response: the request has
+		// already run, and a 500 would have the client retry it. The key is
+		// left held until lockTTL, so retries meanwhile get a 409.
+		if status := c.Response().StatusCode(); !settled(status) {
+			err = s.Release(ctx, key)
+		} else {
+			err = s.Complete(ctx, key, capture(c))
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "could not keep idempotent response",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		return nil
+	}
+}
+
+// settled reports whether a response with status is the final answer to its
+// request.
+func settled(status int) bool {
+	switch {
+	case status >= 500:
+		return false
+	case status == fiber.StatusConflict, status == fiber.StatusTooManyRequests:
+		return false
+	default:
+		return true
+	}
+}
+
+// fingerprint identifies a request by its method, path, credentials and body,
+// so a key replays nothing to anyone but whoever first sent it.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Get(fiber.HeaderAuthorization)))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayedHeaders are the response headers kept with a response.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag, fiber.HeaderLocation}
+
+func capture(c *fiber.Ctx) Response {
+	response := Response{
+		Status:  c.Response().StatusCode(),
+		Headers: map[string]string{},
+		Body:    append([]byte(nil), c.Response().Body()...),
+	}
+
+	for _, name := range replayedHeaders {
+		if value := c.GetRespHeader(name); value != "" {
+			response.Headers[name] = value
+		}
+	}
+
+	return response
+}
+
+func replay(c *fiber.Ctx, response *Response) error {
+	for name, value := range response.Headers {
+		c.Set(name, value)
+	}
+	c.Set(ReplayedHeader, "true")
+
+	return c.Status(response.Status).Send(response.Body)
+}
+
+func problem(c *fiber.Ctx, status int, detail string) error {
+	p := validation.NewProblem(status, detail)
+	p.Instance = c.OriginalURL()
+
+	return c.Status(status).JSON(p, validation.ContentType)
+}
@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore checks the behaviour every Store must share.
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	response, err := s.Begin(ctx, "a", "first")
+	require.NoError(t, err)
+	require.Nil(t, response)
+
+	_, err = s.Begin(ctx, "a", "first")
+	require.ErrorIs(t, err, ErrInProgress)
+	_, err = s.Begin(ctx, "a", "second")
+	require.ErrorIs(t, err, ErrMismatch)
+
+	kept := Response{Status: 202, Headers: map[string]string{"Content-Type": "application/json"}, Body: []byte(`{"ok":true}`)}
+	require.NoError(t, s.Complete(ctx, "a", kept))
+
+	response, err = s.Begin(ctx, "a", "first")
+	require.NoError(t, err)
+	require.Equal(t, &kept, response)
+	_, err = s.Begin(ctx, "a", "second")
+	require.ErrorIs(t, err, ErrMismatch)
+
+	// a released key can be claimed again, by any request
+	_, err = s.Begin(ctx, "b", "first")
+	require.NoError(t, err)
+	require.NoError(t, s.Release(ctx, "b"))
+
+	response, err = s.Begin(ctx, "b", "second")
+	require.NoError(t, err)
+	require.Nil(t, response)
+}
+
+func Test_MemoryStore(t *testing.T) {
+	s := NewMemoryStore(time.Hour)
+	testStore(t, s)
+
+	// an abandoned claim and a kept response both expire
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	_, err := s.Begin(context.Background(), "c", "first")
+	require.NoError(t, err)
+	now = now.Add(lockTTL)
+	_, err = s.Begin(context.Background(), "c", "second")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Complete(context.Background(), "c", Response{Status: 200}))
+	now = now.Add(time.Hour)
+	response, err := s.Begin(context.Background(), "c", "third")
+	require.NoError(t, err)
+	require.Nil(t, response)
+}
+
+func Test_RedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	testStore(t, s)
+
+	_, err := s.Begin(context.Background(), "c", "first")
+	require.NoError(t, err)
+	require.Equal(t, lockTTL, mr.TTL("idempotency:c"))
+
+	require.NoError(t, s.Complete(context.Background(), "c", Response{Status: 200}))
+	require.Equal(t, time.Hour, mr.TTL("idempotency:c"))
+}
+
+func Test_Middleware(t *testing.T) {
+	var calls atomic.Int64
+	status := fiber.StatusAccepted
+
+	app := fiber.New()
+	app.Post("/orders/:id", Middleware(NewMemoryStore(time.Hour)), func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		c.Set(fiber.HeaderETag, `"1"`)
+		return c.Status(status).JSON(fiber.Map{"call": n})
+	})
+
+	send := func(path, key, body string) (int, string, map[string]string) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+
+		res, err := app.Test(req)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		return res.StatusCode, string(data), map[string]string{
+			fiber.HeaderETag: res.Header.Get(fiber.HeaderETag),
+			ReplayedHeader:   res.Header.Get(ReplayedHeader),
+		}
+	}
+
+	code, body, headers := send("/orders/1", "k1", "{}")
+	require.Equal(t, fiber.StatusAccepted, code)
+	require.JSONEq(t, `{"call":1}`, body)
+	require.Empty(t, headers[ReplayedHeader])
+
+	// a retry gets the first response back without running again
+	code, body, headers = send("/orders/1", "k1", "{}")
+	require.Equal(t, fiber.StatusAccepted, code)
+	require.JSONEq(t, `{"call":1}`, body)
+	require.Equal(t, `"1"`, headers[fiber.HeaderETag])
+	require.Equal(t, "true", headers[ReplayedHeader])
+	require.EqualValues(t, 1, calls.Load())
+
+	// the key cannot be reused for something else
+	code, _, _ = send("/orders/2", "k1", "{}")
+	require.Equal(t, fiber.StatusUnprocessableEntity, code)
+	code, _, _ = send("/orders/1", "k1", `{"other":true}`)
+	require.Equal(t, fiber.StatusUnprocessableEntity, code)
+
+	// requests without a key are not affected
+	send("/orders/1", "", "{}")
+	send("/orders/1", "", "{}")
+	require.EqualValues(t, 3, calls.Load())
+
+	// a conflict can be retried with the same key once it is resolved
+	status = fiber.StatusConflict
+	code, _, _ = send("/orders/3", "k2", "{}")
+	require.Equal(t, fiber.StatusConflict, code)
+
+	status = fiber.StatusAccepted
+	code, body, _ = send("/orders/3", "k2", "{}")
+	require.Equal(t, fiber.StatusAccepted, code)
+	require.JSONEq(t, `{"call":5}`, body)
+
+	code, _, _ = send("/orders/3", strings.Repeat("k", maxKeyLength+1), "{}")
+	require.Equal(t, fiber.StatusBadRequest, code)
+}
+
+// failingStore cannot keep responses.
+type failingStore struct {
+	Store
+}
+
+func (failingStore) Complete(ctx context.Context, key string, response Response) error {
+	return errors.New("connection reset")
+}
+
+func Test_Middleware_KeepFails(t *testing.T) {
+	app := fiber.New()
+	app.Post("/orders/:id", Middleware(failingStore{Store: NewMemoryStore(time.Hour)}), func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"ok": true})
+	})
+
+	req := httptest.NewRequest("POST", "/orders/1", strings.NewReader("{}"))
+	req.Header.Set(Header, "k1")
+
+	// the order went through, so the client must not be told otherwise
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusAccepted, res.StatusCode)
+}
@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    record
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store held in process memory. It is intended for tests and
+// local runs; nothing survives a restart and keys are not shared between
+// instances.
+type MemoryStore struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	keys map[string]memoryEntry
+
+	// now is swapped out in tests to control expiry.
+	now func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:  ttl,
+		keys: make(map[string]memoryEntry),
+		now:  time.Now,
+	}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.keys[key]; ok && s.now().Before(entry.expiresAt) {
+		return entry.record.begin(fingerprint)
+	}
+
+	s.keys[key] = memoryEntry{
+		record:    record{Fingerprint: fingerprint},
+		expiresAt: s.now().Add(lockTTL),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.keys[key]
+	entry.record.Response = &response
+	entry.expiresAt = s.now().Add(s.ttl)
+	s.keys[key] = entry
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
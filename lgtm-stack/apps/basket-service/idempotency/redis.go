@@ -0,0 +1,89 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store backed by Redis. Each key is a JSON record under
+// "idempotency:{key}" that expires after a minute while its request runs and
+// after the store's TTL once it has completed.
+type RedisStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+var _ Store = (*RedisStore)(nil)
+
+func NewRedisStore(rdb *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{rdb: rdb, ttl: ttl}
+}
+
+func idempotencyKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+// beginScript claims the key and returns nothing, or returns the record that
+// already holds it.
+var beginScript = redis.NewScript(`
+local existing = redis.call('GET', KEYS[1])
+if existing then
+	return existing
+end
+
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return false
+`)
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	claim, err := json.Marshal(record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := beginScript.Run(ctx, s.rdb, []string{idempotencyKey(key)}, claim, lockTTL.Milliseconds()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r record
+	if err := json.Unmarshal([]byte(existing), &r); err != nil {
+		return nil, fmt.Errorf("idempotency key %s has an invalid record: %w", key, err)
+	}
+
+	return r.begin(fingerprint)
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, response Response) error {
+	existing, err := s.rdb.Get(ctx, idempotencyKey(key)).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	var r record
+	if existing != nil {
+		if err := json.Unmarshal(existing, &r); err != nil {
+			return fmt.Errorf("idempotency key %s has an invalid record: %w", key, err)
+		}
+	}
+	r.Response = &response
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, idempotencyKey(key), data, s.ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, idempotencyKey(key)).Err()
+}
@@ -1,219 +1,283 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"os"
-	"strconv"
 	"time"
 
+	"basket-service/abandoned"
+	"basket-service/auth"
+	"basket-service/coupons"
+	"basket-service/idempotency"
+	"basket-service/model"
+	"basket-service/outbox"
+	"basket-service/pricing"
+	"basket-service/products"
+	"basket-service/rabbitmq"
+	"basket-service/store"
+	"basket-service/telemetry"
+	"service-kit/config"
+	"service-kit/health"
+	"service-kit/lifecycle"
+
+	"github.com/gofiber/contrib/otelfiber/v2"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-type Basket struct {
-	Id    uuid.UUID    `json:"id"`
-	Items []BasketItem `json:"items"`
-}
+const (
+	basketTTL   = 24 * time.Hour
+	ordersQueue = "orders"
 
-type BasketItem struct {
-	Id        uuid.UUID `json:"id"`
-	ProductId uuid.UUID `json:"catalogId"`
-	Price     float64   `json:"price"`
-	Quantity  uint      `json:"quantity"`
-}
+	// idempotencyTTL is how long a checkout's response is kept for retries.
+	// It matches the basket TTL, since a basket cannot be checked out once it
+	// has gone.
+	idempotencyTTL = basketTTL
 
-type CreateBasketRequest struct {
-	Items []CreateBasketItemRequest `json:"items"`
-}
+	orderRequestedEvent = "order.requested"
+)
 
-type CreateBasketItemRequest struct {
-	ProductId string `json:"catalogId"`
-	Quantity  uint   `json:"quantity"`
-}
+func main() {
+	slog.SetDefault(telemetry.NewLogger(os.Stdout))
 
-type ProductResponse struct {
-	Id          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-}
+	var cfg Config
+	config.LoadOrExit(&cfg)
+
+	lc := lifecycle.New(cfg.ShutdownTimeout)
+
+	metrics, shutdown, err := telemetry.Setup(context.Background(), "basket-service")
+	failOnError(err, "Failed to set up OpenTelemetry")
+	lc.OnShutdown("OpenTelemetry", shutdown)
 
-func main() {
 	app := fiber.New()
 
-	ch := connectToRabbitMQ()
-	redis := connectToRedis()
+	ready := health.NewChecker(2 * time.Second)
 
-	app.Get("/api/basket/:id", func(c *fiber.Ctx) error {
-		id, err := uuid.Parse(c.Params("id"))
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse id to UUID"})
-		}
+	// probes and scrapes are registered before the middleware, so they are
+	// not traced or logged
+	app.Get("/healthz", adaptor.HTTPHandler(health.Live()))
+	app.Get("/readyz", adaptor.HTTPHandler(ready))
+	app.Get("/metrics", adaptor.HTTPHandler(metrics))
 
-		value, err := redis.Get(c.Context(), id.String()).Result()
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
-		}
+	app.Use(otelfiber.Middleware(otelfiber.WithSpanNameFormatter(func(c *fiber.Ctx) string {
+		return fmt.Sprintf("%s %s", c.Method(), c.Route().Path)
+	})))
+	app.Use(telemetry.RequestLogger())
 
-		var basket Basket
-		if err := json.Unmarshal([]byte(value), &basket); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Record invalid"})
-		}
+	requestMetrics, err := telemetry.RequestMetrics()
+	failOnError(err, "Failed to create request metrics")
+	app.Use(requestMetrics)
 
-		return c.Status(fiber.StatusOK).JSON(basket)
-	})
+	if cfg.AuthSecret == "" {
+		slog.Warn("AUTH_SECRET is not set, only anonymous baskets can be used")
+	}
+	app.Use(auth.Middleware(auth.NewVerifier([]byte(cfg.AuthSecret))))
+
+	baskets, couponStore, idempotencyKeys := connectToStore(cfg, lc, ready)
+	loadCoupons(cfg.CouponsFile, couponStore)
 
-	app.Post("/api/basket", func(c *fiber.Ctx) error {
-		var request CreateBasketRequest
-		if err := c.BodyParser(&request); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
-		}
-
-		// todo: make better
-		if len(request.Items) <= 0 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "At least one product is required"})
-		}
-
-		for _, item := range request.Items {
-			_, err := uuid.Parse(item.ProductId)
-			if err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Product id must be a valid id"})
-			}
-
-			if item.Quantity <= 0 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Quantity must be greater than 1"})
-			}
-		}
-
-		basket := Basket{
-			Id: uuid.New(),
-		}
-
-		for _, item := range request.Items {
-			uri := fmt.Sprintf("%s/api/products/%s", os.Getenv("PRODUCT_SERVICE_BASE_URL"), item.ProductId)
-			res, err := http.Get(uri)
-			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not validate product"})
-			}
-			defer res.Body.Close()
-
-			if res.StatusCode != fiber.StatusOK {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Invalid product"})
-			}
-
-			var product ProductResponse
-			if err := json.NewDecoder(res.Body).Decode(&product); err != nil {
-				return err
-			}
-
-			basketItem := BasketItem{
-				Id:        uuid.New(),
-				ProductId: uuid.MustParse(item.ProductId),
-				Price:     product.Price,
-				Quantity:  item.Quantity,
-			}
-
-			basket.Items = append(basket.Items, basketItem)
-		}
-
-		if err := redis.Set(c.Context(), basket.Id.String(), basket, 24*time.Hour).Err(); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not validate product"})
-		}
-
-		return c.Status(fiber.StatusOK).JSON(basket)
+	rabbit := connectToRabbitMQ(cfg.RabbitMQ)
+	lc.OnShutdown("RabbitMQ", rabbit.Shutdown)
+	ready.Add("rabbitmq", func(context.Context) error {
+		return rabbit.Ping()
 	})
 
-	app.Get("/api/basket/:id/checkout", func(c *fiber.Ctx) error {
-		id, err := uuid.Parse(c.Params("id"))
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Could not parse id to UUID"})
-		}
-
-		value, err := redis.Get(c.Context(), id.String()).Result()
-		if err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Record not found"})
-		}
-
-		var basket Basket
-		if err := json.Unmarshal([]byte(value), &basket); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Record invalid"})
-		}
-
-		q, err := ch.QueueDeclare(
-			"orders", // name
-			true,     // durable
-			false,    // delete when unused
-			false,    // exclusive
-			false,    // no-wait
-			nil,      // arguments
-		)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Unable to checkout basket"})
-		}
-
-		if err := ch.PublishWithContext(c.Context(),
-			"",     // exchange
-			q.Name, // routing key
-			false,  // mandatory
-			false,  // immediate
-			amqp091.Publishing{
-				Body: []byte(value),
-			}); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Unable to checkout basket"})
-		}
-
-		return c.SendStatus(fiber.StatusNoContent)
+	relay, err := outbox.NewRelay(baskets, rabbit, outbox.RelayOptions{})
+	failOnError(err, "Failed to create outbox relay")
+	lc.Go(relay.Run)
+
+	// publish whatever the last requests wrote before RabbitMQ is closed
+	lc.OnShutdown("outbox relay", relay.Flush)
+
+	engine, err := pricing.New(model.Currency, pricingConfig(cfg.PricingConfig))
+	failOnError(err, "Failed to create pricing engine")
+
+	worker, err := abandoned.NewWorker(baskets, couponStore, engine, abandoned.Options{
+		After:           cfg.AbandonedAfter,
+		CheckoutTimeout: cfg.CheckoutTimeout,
+		ExpireAfter:     cfg.ExpireAfter,
 	})
+	failOnError(err, "Failed to create abandoned basket worker")
+	lc.Go(worker.Run)
+
+	productClient := products.New(
+		cfg.ProductServiceURL,
+		&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		products.Options{Currency: model.Currency},
+	)
+	ready.Add("product-service", productClient.Ping)
+
+	basketMetrics, err := newBasketMetrics()
+	failOnError(err, "Failed to create basket metrics")
+
+	h := &handler{
+		baskets:        baskets,
+		coupons:        couponStore,
+		pricing:        engine,
+		products:       productClient,
+		priceTolerance: cfg.PriceDriftTolerance,
+		metrics:        basketMetrics,
+	}
+
+	h.routes(app, idempotencyKeys)
+
+	if err := lc.Run(func() error { return app.Listen(fmt.Sprintf(":%d", cfg.Port)) }, app.ShutdownWithContext); err != nil {
+		slog.Error("Shut down with errors", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// routes registers the basket API on r. Checkouts are made idempotent with
+// keys kept in idempotencyKeys.
+func (h *handler) routes(r fiber.Router, idempotencyKeys idempotency.Store) {
+	r.Get("/api/basket/me", h.getCurrentBasket)
+	r.Post("/api/basket/me/merge", h.mergeBasket)
+	r.Get("/api/basket/:id", h.getBasket)
+	r.Post("/api/basket", h.createBasket)
+	r.Delete("/api/basket/:id", h.deleteBasket)
+	r.Patch("/api/basket/:id/items", h.addBasketItems)
+	r.Delete("/api/basket/:id/items", h.clearBasket)
+	r.Put("/api/basket/:id/items/:itemId", h.updateBasketItem)
+	r.Delete("/api/basket/:id/items/:itemId", h.removeBasketItem)
+	r.Post("/api/basket/:id/coupons", h.applyCoupon)
+	r.Delete("/api/basket/:id/coupons/:code", h.removeCoupon)
+	r.Post("/api/basket/:id/checkout", idempotency.Middleware(idempotencyKeys), h.checkout)
+}
+
+// pricingConfig loads the tax, shipping and promotion rules from the JSON file
+// at path, or uses pricing.DefaultConfig if there is none.
+func pricingConfig(path string) pricing.Config {
+	if path == "" {
+		return pricing.DefaultConfig(model.Currency)
+	}
 
-	app.Listen(":8080")
+	config, err := pricing.LoadConfig(path)
+	failOnError(err, "Failed to load pricing config")
+
+	return config
 }
 
-func connectToRedis() *redis.Client {
-	server := os.Getenv("REDIS_HOST")
-	port, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
-	failOnError(err, "Could parse REDIS_PORT to int")
-	pass := os.Getenv("REDIS_PASSWORD")
+// connectToStore returns the BasketStore, coupons.Store and idempotency.Store
+// for the configured store. The connections are checked for readiness, and
+// closed at shutdown.
+func connectToStore(cfg Config, lc *lifecycle.Manager, ready *health.Checker) (store.BasketStore, coupons.Store, idempotency.Store) {
+	switch cfg.Store {
+	case "redis":
+		rdb := connectToRedis(cfg.Redis)
+		lc.OnShutdown("Redis", lifecycle.Close(rdb.Close))
+		ready.Add("redis", pingRedis(rdb))
+		return store.NewRedisStore(rdb, basketTTL), coupons.NewRedisStore(rdb), idempotency.NewRedisStore(rdb, idempotencyTTL)
+	case "postgres":
+		pool := connectToPostgres(cfg.Postgres)
+		lc.OnShutdown("Postgres", func(context.Context) error {
+			pool.Close()
+			return nil
+		})
+		ready.Add("postgres", pool.Ping)
+		s, err := store.NewPostgresStore(context.Background(), pool, basketTTL)
+		failOnError(err, "Failed to create baskets table")
+		c, err := coupons.NewPostgresStore(context.Background(), pool)
+		failOnError(err, "Failed to create coupons tables")
+		rdb := connectToRedis(cfg.Redis)
+		lc.OnShutdown("Redis", lifecycle.Close(rdb.Close))
+		ready.Add("redis", pingRedis(rdb))
+		return s, c, idempotency.NewRedisStore(rdb, idempotencyTTL)
+	case "memory":
+		return store.NewMemoryStore(basketTTL), coupons.NewMemoryStore(), idempotency.NewMemoryStore(idempotencyTTL)
+	default:
+		// config.Load has already checked the store is one of the above
+		panic("unknown store " + cfg.Store)
+	}
+}
+
+// loadCoupons writes the coupons in the JSON file at path, if there is one, to
+// the coupon store. Coupons already in the store keep their redemptions.
+func loadCoupons(path string, s coupons.Store) {
+	if path == "" {
+		return
+	}
+
+	loaded, err := coupons.LoadFile(path)
+	failOnError(err, "Failed to load coupons")
+
+	for _, coupon := range loaded {
+		failOnError(s.Put(context.Background(), coupon), "Failed to save coupon "+coupon.Code)
+	}
+}
 
+func connectToRedis(cfg config.Redis) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", server, port),
-		Password: pass,
+		Addr:     cfg.Addr(),
+		Password: cfg.Password,
 		DB:       0,
 	})
 
+	failOnError(redisotel.InstrumentTracing(rdb), "Failed to instrument Redis tracing")
+	failOnError(redisotel.InstrumentMetrics(rdb), "Failed to instrument Redis metrics")
+
 	return rdb
 }
 
-func connectToRabbitMQ() *amqp091.Channel {
-	user := os.Getenv("RABBITMQ_USERNAME")
-	pass := os.Getenv("RABBITMQ_PASSWORD")
-	host := os.Getenv("RABBITMQ_HOST")
-	port := os.Getenv("RABBITMQ_PORT")
-
-	u := &url.URL{
-		Scheme: "amqp",
-		User:   url.UserPassword(user, pass),
-		Host:   fmt.Sprintf("%s:%s", host, port),
+func pingRedis(rdb *redis.Client) health.Check {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
 	}
+}
+
+func connectToPostgres(cfg config.Postgres) *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), cfg.URL())
+	failOnError(err, "Failed to connect to Postgres")
 
-	conn, err := amqp091.Dial(u.String())
+	return pool
+}
+
+func connectToRabbitMQ(cfg config.RabbitMQ) *rabbitmq.Manager {
+	m, err := rabbitmq.Dial(cfg.URL(), declareTopology, rabbitmq.Options{})
 	failOnError(err, "Failed to connect to RabbitMQ")
-	defer conn.Close()
 
-	ch, err := conn.Channel()
-	failOnError(err, "Failed to open a channel")
-	defer ch.Close()
+	return m
+}
+
+// declareTopology declares everything the service publishes to. It runs on
+// every RabbitMQ (re)connect. Consumers of basket events bind their own queues
+// to the baskets exchange.
+func declareTopology(ch *amqp091.Channel) error {
+	_, err := ch.QueueDeclare(
+		ordersQueue, // name
+		true,        // durable
+		false,       // delete when unused
+		false,       // exclusive
+		false,       // no-wait
+		nil,         // arguments
+	)
+	if err != nil {
+		return err
+	}
 
-	return ch
+	return ch.ExchangeDeclare(
+		abandoned.Exchange, // name
+		"topic",            // kind
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
 }
 
+// failOnError exits if the service could not start. It is only for startup;
+// requests report their errors to the caller.
 func failOnError(err error, msg string) {
 	if err != nil {
-		log.Panicf("%s: %s", msg, err)
+		slog.Error(msg, slog.Any("error", err))
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"basket-service/model"
+	"basket-service/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "basket-service"
+
+// basketMetrics are the business metrics the handlers record. Measurements
+// are taken with the request's context, so they carry its trace as an
+// exemplar.
+type basketMetrics struct {
+	created   metric.Int64Counter
+	items     metric.Int64Histogram
+	value     metric.Float64Histogram
+	checkouts metric.Int64Counter
+}
+
+func newBasketMetrics() (*basketMetrics, error) {
+	meter := otel.Meter(instrumentationName)
+
+	created, err := meter.Int64Counter("basket.created",
+		metric.WithDescription("Baskets created, by whether the customer was signed in"))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := meter.Int64Histogram("basket.items",
+		metric.WithDescription("Items in each basket checked out"),
+		metric.WithExplicitBucketBoundaries(1, 2, 3, 5, 8, 13, 21, 34, 55))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := meter.Float64Histogram("basket.value",
+		metric.WithDescription("Total of each basket checked out, in its currency"),
+		metric.WithExplicitBucketBoundaries(5, 10, 20, 50, 100, 200, 500, 1000, 2000))
+	if err != nil {
+		return nil, err
+	}
+
+	checkouts, err := meter.Int64Counter("basket.checkouts",
+		metric.WithDescription("Checkouts by result, and the reason for those that failed"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &basketMetrics{
+		created:   created,
+		items:     items,
+		value:     value,
+		checkouts: checkouts,
+	}, nil
+}
+
+// basketCreated counts a new basket.
+func (m *basketMetrics) basketCreated(ctx context.Context, basket *model.Basket) {
+	m.created.Add(ctx, 1, metric.WithAttributes(
+		attribute.Bool("signed_in", basket.OwnerId != ""),
+	))
+}
+
+// checkedOut counts a checkout that failed with err, or records the size and
+// value of order if it succeeded.
+func (m *basketMetrics) checkedOut(ctx context.Context, order pricedBasket, err error) {
+	if err != nil {
+		m.checkouts.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("result", "failure"),
+			attribute.String("reason", checkoutFailure(err)),
+		))
+		return
+	}
+
+	m.checkouts.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("result", "success"),
+		attribute.String("reason", ""),
+	))
+
+	m.items.Record(ctx, int64(len(order.Basket.Items)))
+
+	// the histogram is only for seeing how basket values are spread, so the
+	// precision lost in converting to a float does not matter
+	total := order.Pricing.Total
+	if value, err := strconv.ParseFloat(total.Amount(), 64); err == nil {
+		m.value.Record(ctx, value, metric.WithAttributes(
+			attribute.String("currency", total.Currency()),
+			attribute.String("region", order.Pricing.Region),
+		))
+	}
+}
+
+// checkoutFailure names why a checkout failed, from the error it was rejected
+// with.
+func checkoutFailure(err error) string {
+	var changed pricesChangedProblem
+	if errors.As(err, &changed) {
+		return "prices_changed"
+	}
+
+	// the only problems a checkout is turned down with, other than changed
+	// prices, are coupons that can no longer be redeemed
+	var problem validation.Problem
+	if errors.As(err, &problem) {
+		return "coupon_rejected"
+	}
+
+	var response *errorResponse
+	if !errors.As(err, &response) {
+		return "error"
+	}
+
+	switch response.status {
+	case fiber.StatusBadRequest, fiber.StatusNotFound:
+		return "not_found"
+	case fiber.StatusUnauthorized, fiber.StatusForbidden:
+		return "unauthorized"
+	case fiber.StatusConflict:
+		return "conflict"
+	case fiber.StatusServiceUnavailable:
+		return "product_service_unavailable"
+	default:
+		return "error"
+	}
+}
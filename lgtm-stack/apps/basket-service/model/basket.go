@@ -0,0 +1,207 @@
+package model
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is the version of the stored Basket shape written by
+// MarshalBinary. Bump it whenever that shape changes and register a migration
+// from the previous version in migrations.
+const SchemaVersion = 7
+
+// Currency is the currency of every basket. product-service prices carry no
+// currency of their own, so they are all taken to be in it.
+const Currency = "GBP"
+
+// migrations upgrade a basket decoded at version n (the map key) to version
+// n+1. UnmarshalBinary applies them in order until the basket reaches
+// SchemaVersion.
+var migrations = map[int]func(*Basket) error{
+	// baskets written before the schema was versioned have no schemaVersion
+	// but are otherwise identical to version 1
+	0: func(b *Basket) error { return nil },
+
+	// version 2 added Status; every basket stored before then was still open
+	1: func(b *Basket) error {
+		b.Status = StatusOpen
+		return nil
+	},
+
+	// version 3 replaced float prices with money.Money; the old numbers decode
+	// exactly but without a currency
+	2: func(b *Basket) error {
+		for i := range b.Items {
+			b.Items[i].Price = b.Items[i].Price.WithCurrency(Currency)
+		}
+		return nil
+	},
+
+	// version 4 added Region; baskets without one are priced for the default
+	// region
+	3: func(b *Basket) error { return nil },
+
+	// version 5 added Coupons, which older baskets never had
+	4: func(b *Basket) error { return nil },
+
+	// version 6 added History; the transitions of older baskets were never
+	// recorded
+	5: func(b *Basket) error { return nil },
+
+	// version 7 added UpdatedAt, which older baskets get on their next write
+	6: func(b *Basket) error { return nil },
+}
+
+type Basket struct {
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
+	// Version is incremented by the store on every write and is what the
+	// basket's ETag is made from, see store.ErrConflict.
+	Version uint64 `json:"version"`
+
+	// UpdatedAt is when the basket was last written, set by the store along
+	// with Version. It is nil for a basket not written since version 7.
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+
+	Id      uuid.UUID    `json:"id"`
+	OwnerId string       `json:"ownerId,omitempty"`
+	Status  Status       `json:"status"`
+	Items   []BasketItem `json:"items"`
+
+	// Region is where the basket will be delivered, which decides its tax and
+	// shipping. Empty means the pricing engine's default region.
+	Region string `json:"region,omitempty"`
+
+	// Coupons are the coupon codes applied to the basket, normalized by
+	// coupons.Normalize. They are redeemed when the basket is checked out.
+	Coupons []string `json:"coupons,omitempty"`
+
+	// History is every status change the basket has been through, oldest
+	// first. It is only ever appended to, by Transition.
+	History []Transition `json:"history,omitempty"`
+}
+
+var (
+	_ encoding.BinaryMarshaler   = Basket{}
+	_ encoding.BinaryUnmarshaler = (*Basket)(nil)
+)
+
+// MarshalBinary encodes the basket as JSON stamped with the current
+// SchemaVersion. It lets a Basket be passed straight to go-redis.
+func (b Basket) MarshalBinary() ([]byte, error) {
+	b.SchemaVersion = SchemaVersion
+	return json.Marshal(b)
+}
+
+// UnmarshalBinary decodes a basket written by MarshalBinary at any schema
+// version up to SchemaVersion, migrating it to the current shape.
+func (b *Basket) UnmarshalBinary(data []byte) error {
+	var decoded Basket
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("basket %s has schema version %d, newest supported is %d", decoded.Id, decoded.SchemaVersion, SchemaVersion)
+	}
+
+	for decoded.SchemaVersion < SchemaVersion {
+		migrate, ok := migrations[decoded.SchemaVersion]
+		if !ok {
+			return fmt.Errorf("no migration for basket schema version %d", decoded.SchemaVersion)
+		}
+
+		if err := migrate(&decoded); err != nil {
+			return fmt.Errorf("migrating basket %s from schema version %d: %w", decoded.Id, decoded.SchemaVersion, err)
+		}
+
+		decoded.SchemaVersion++
+	}
+
+	*b = decoded
+	return nil
+}
+
+// Touch sets UpdatedAt to at. Stores call it on every write.
+func (b *Basket) Touch(at time.Time) {
+	at = at.UTC()
+	b.UpdatedAt = &at
+}
+
+// LastWritten returns UpdatedAt, or the zero time if the basket has none.
+func (b *Basket) LastWritten() time.Time {
+	if b.UpdatedAt == nil {
+		return time.Time{}
+	}
+
+	return *b.UpdatedAt
+}
+
+// Item returns the item with the given id, or nil if the basket has none.
+func (b *Basket) Item(id uuid.UUID) *BasketItem {
+	for i := range b.Items {
+		if b.Items[i].Id == id {
+			return &b.Items[i]
+		}
+	}
+
+	return nil
+}
+
+// AddItem adds quantity of a product to the basket. If the basket already has
+// an item for the product its quantity is increased and its price left alone,
+// otherwise a new item is added at price.
+func (b *Basket) AddItem(productId uuid.UUID, price money.Money, quantity uint) {
+	for i := range b.Items {
+		if b.Items[i].ProductId == productId {
+			b.Items[i].Quantity += quantity
+			return
+		}
+	}
+
+	b.Items = append(b.Items, BasketItem{
+		Id:        uuid.New(),
+		ProductId: productId,
+		Price:     price,
+		Quantity:  quantity,
+	})
+}
+
+// RemoveItem removes the item with the given id and reports whether there was
+// one.
+func (b *Basket) RemoveItem(id uuid.UUID) bool {
+	for i := range b.Items {
+		if b.Items[i].Id == id {
+			b.Items = append(b.Items[:i], b.Items[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+type BasketItem struct {
+	Id        uuid.UUID   `json:"id"`
+	ProductId uuid.UUID   `json:"catalogId"`
+	Price     money.Money `json:"price"`
+	Quantity  uint        `json:"quantity"`
+}
+
+var (
+	_ encoding.BinaryMarshaler   = BasketItem{}
+	_ encoding.BinaryUnmarshaler = (*BasketItem)(nil)
+)
+
+func (i BasketItem) MarshalBinary() ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (i *BasketItem) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, i)
+}
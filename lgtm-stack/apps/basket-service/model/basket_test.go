@@ -0,0 +1,166 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"money-go"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedis(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return rdb
+}
+
+func Test_Basket_RedisRoundTrip(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	basket := Basket{
+		Id: uuid.New(),
+		Items: []BasketItem{
+			{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("25360.05", Currency), Quantity: 1},
+			{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("0.33", Currency), Quantity: 3},
+		},
+	}
+
+	require.NoError(t, rdb.Set(ctx, basket.Id.String(), basket, 24*time.Hour).Err())
+
+	var got Basket
+	require.NoError(t, rdb.Get(ctx, basket.Id.String()).Scan(&got))
+
+	basket.SchemaVersion = SchemaVersion
+	require.Equal(t, basket, got)
+}
+
+func Test_BasketItem_RedisRoundTrip(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	item := BasketItem{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("3.33", Currency), Quantity: 2}
+	require.NoError(t, rdb.Set(ctx, item.Id.String(), item, 0).Err())
+
+	var got BasketItem
+	require.NoError(t, rdb.Get(ctx, item.Id.String()).Scan(&got))
+	require.Equal(t, item, got)
+}
+
+func Test_Basket_MarshalBinary_StampsSchemaVersion(t *testing.T) {
+	data, err := Basket{Id: uuid.New()}.MarshalBinary()
+	require.NoError(t, err)
+	require.Contains(t, string(data), fmt.Sprintf(`"schemaVersion":%d`, SchemaVersion))
+}
+
+func Test_Basket_UpdatedAt(t *testing.T) {
+	basket := Basket{Id: uuid.New()}
+	data, err := basket.MarshalBinary()
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "updatedAt", "a basket never written has no UpdatedAt")
+	require.True(t, basket.LastWritten().IsZero())
+
+	at := time.Date(2026, time.October, 18, 13, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	basket.Touch(at)
+
+	var got Basket
+	data, err = basket.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, got.UnmarshalBinary(data))
+	require.Equal(t, at.UTC(), *got.UpdatedAt)
+	require.Equal(t, at.UTC(), got.LastWritten())
+}
+
+func Test_Basket_UnmarshalBinary_MigratesUnversioned(t *testing.T) {
+	rdb := newRedis(t)
+	ctx := context.Background()
+
+	// the shape written by json.Marshal before baskets were versioned
+	legacy := `{"id":"0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10","items":[{"id":"5d3b9a3c-1f2e-4b6a-8c7d-9e0f1a2b3c4d","catalogId":"72119506-89ef-4c0c-ace7-6cbd984bfc50","price":10.5,"quantity":2}]}`
+	require.NoError(t, rdb.Set(ctx, "legacy", legacy, 0).Err())
+
+	var got Basket
+	require.NoError(t, rdb.Get(ctx, "legacy").Scan(&got))
+	require.Equal(t, SchemaVersion, got.SchemaVersion)
+	require.Equal(t, StatusOpen, got.Status)
+	require.Equal(t, uuid.MustParse("0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10"), got.Id)
+	require.Len(t, got.Items, 1)
+	require.Equal(t, money.MustParse("10.50", Currency), got.Items[0].Price)
+	require.Equal(t, uint(2), got.Items[0].Quantity)
+}
+
+func Test_Basket_UnmarshalBinary_RejectsNewerSchema(t *testing.T) {
+	var got Basket
+	err := got.UnmarshalBinary([]byte(`{"schemaVersion":99,"id":"0b6a3c3e-8f1e-4a44-9b53-6c8d1f1b5a10","items":[]}`))
+	require.ErrorContains(t, err, "schema version 99")
+}
+
+func Test_Basket_AddItem_MergesByProduct(t *testing.T) {
+	mug, tea := uuid.New(), uuid.New()
+
+	var basket Basket
+	basket.AddItem(mug, money.MustParse("4.99", Currency), 1)
+	basket.AddItem(tea, money.MustParse("2.5", Currency), 3)
+	basket.AddItem(mug, money.MustParse("5.99", Currency), 2)
+
+	require.Len(t, basket.Items, 2)
+	require.Equal(t, mug, basket.Items[0].ProductId)
+	require.Equal(t, uint(3), basket.Items[0].Quantity)
+	require.Equal(t, money.MustParse("4.99", Currency), basket.Items[0].Price, "merging keeps the original price")
+	require.Equal(t, uint(3), basket.Items[1].Quantity)
+}
+
+func Test_Basket_RemoveItem(t *testing.T) {
+	var basket Basket
+	basket.AddItem(uuid.New(), money.New(100, Currency), 1)
+	basket.AddItem(uuid.New(), money.New(100, Currency), 1)
+	id := basket.Items[0].Id
+
+	require.NotNil(t, basket.Item(id))
+	require.True(t, basket.RemoveItem(id))
+	require.Nil(t, basket.Item(id))
+	require.False(t, basket.RemoveItem(id))
+	require.Len(t, basket.Items, 1)
+}
+
+func Test_Basket_Transition(t *testing.T) {
+	at := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	var basket Basket
+	require.NoError(t, basket.Transition(StatusOpen, "alice", "created", at))
+	require.NoError(t, basket.Transition(StatusCheckingOut, "alice", "checkout started", at))
+	require.NoError(t, basket.Transition(StatusOpen, ActorSystem, "prices changed", at))
+	require.NoError(t, basket.Transition(StatusCheckingOut, "alice", "checkout started", at))
+	require.NoError(t, basket.Transition(StatusCheckedOut, "alice", "order requested", at))
+
+	var transitionErr *TransitionError
+	require.ErrorAs(t, basket.Transition(StatusOpen, "alice", "", at), &transitionErr)
+	require.Equal(t, &TransitionError{From: StatusCheckedOut, To: StatusOpen}, transitionErr)
+
+	require.Equal(t, StatusCheckedOut, basket.Status)
+	require.Len(t, basket.History, 5)
+	require.Equal(t, Transition{From: StatusOpen, To: StatusCheckingOut, At: at, Actor: "alice", Reason: "checkout started"}, basket.History[1])
+	require.Equal(t, Transition{To: StatusOpen, At: at, Actor: "alice", Reason: "created"}, basket.History[0])
+}
+
+func Test_Status_CanTransition(t *testing.T) {
+	for _, final := range []Status{StatusCheckedOut, StatusExpired} {
+		for _, to := range []Status{StatusOpen, StatusCheckingOut, StatusCheckedOut, StatusAbandoned, StatusExpired} {
+			require.False(t, final.CanTransition(to), "%s is final", final)
+		}
+		require.True(t, final.Final())
+	}
+	require.False(t, StatusAbandoned.Final())
+
+	require.False(t, StatusOpen.CanTransition(StatusCheckedOut), "checkout always goes through checking_out")
+	require.True(t, StatusAbandoned.CanTransition(StatusOpen))
+	require.False(t, StatusAbandoned.CanTransition(StatusCheckingOut))
+}
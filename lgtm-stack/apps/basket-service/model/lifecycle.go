@@ -0,0 +1,102 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status is where a basket is in its lifecycle. A basket is created open and
+// checking out moves it to checking_out, then on to checked_out once the order
+// is written, or back to open if checkout fails or never finishes. An open
+// basket left alone is abandoned, and open again if the customer comes back
+// to it; one that stays abandoned expires. checked_out and expired are final.
+type Status string
+
+const (
+	StatusOpen        Status = "open"
+	StatusCheckingOut Status = "checking_out"
+	StatusCheckedOut  Status = "checked_out"
+	StatusAbandoned   Status = "abandoned"
+	StatusExpired     Status = "expired"
+)
+
+// ActorSystem is the actor recorded for transitions the service makes on its
+// own rather than at a customer's request.
+const ActorSystem = "system"
+
+// transitions lists the statuses each status may move to. The empty status is
+// a basket that has not been created yet.
+var transitions = map[Status][]Status{
+	"":                {StatusOpen},
+	StatusOpen:        {StatusCheckingOut, StatusAbandoned},
+	StatusCheckingOut: {StatusCheckedOut, StatusOpen},
+	StatusAbandoned:   {StatusOpen, StatusExpired},
+}
+
+// CanTransition reports whether a basket may move from s to to.
+func (s Status) CanTransition(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Final reports whether a basket in this status is done with, never to change
+// again.
+func (s Status) Final() bool {
+	return s != "" && len(transitions[s]) == 0
+}
+
+// Editable reports whether items and coupons can be changed in this status.
+// Changing an abandoned basket reopens it.
+func (s Status) Editable() bool {
+	return s == StatusOpen || s == StatusAbandoned
+}
+
+// Deletable reports whether a basket in this status can be deleted. One being
+// checked out, or that has been, is kept for its order.
+func (s Status) Deletable() bool {
+	return s.Editable()
+}
+
+// Transition is one entry in a basket's history.
+type Transition struct {
+	From   Status    `json:"from,omitempty"`
+	To     Status    `json:"to"`
+	At     time.Time `json:"at"`
+	Actor  string    `json:"actor"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// TransitionError is returned for a status change the lifecycle does not
+// allow.
+type TransitionError struct {
+	From, To Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("basket cannot go from %q to %q", e.From, e.To)
+}
+
+// Transition moves the basket to status to, recording who did it and why in
+// its history. It is the only way a basket's status should change, and returns
+// a *TransitionError, leaving the basket untouched, if the move is not
+// allowed.
+func (b *Basket) Transition(to Status, actor, reason string, at time.Time) error {
+	if !b.Status.CanTransition(to) {
+		return &TransitionError{From: b.Status, To: to}
+	}
+
+	b.History = append(b.History, Transition{
+		From:   b.Status,
+		To:     to,
+		At:     at.UTC(),
+		Actor:  actor,
+		Reason: reason,
+	})
+	b.Status = to
+	return nil
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Event is a message waiting to be published. It is written in the same
+// transaction as the state change that caused it and published later by a
+// Relay, so a crash between the two can neither lose nor invent it.
+type Event struct {
+	Id         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	Exchange   string    `json:"exchange"`
+	RoutingKey string    `json:"routingKey"`
+	Body       []byte    `json:"body"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	// Headers carries the trace context of the request that produced the
+	// event so the published message joins the same trace.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// NewEvent returns an event of the given type whose body is payload encoded as
+// JSON.
+func NewEvent(ctx context.Context, eventType, exchange, routingKey string, payload any) (Event, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	headers := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	return Event{
+		Id:         uuid.New(),
+		Type:       eventType,
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Body:       body,
+		CreatedAt:  time.Now().UTC(),
+		Headers:    headers,
+	}, nil
+}
+
+// eventNamespace is the UUID namespace that EventId derives ids in.
+var eventNamespace = uuid.MustParse("1b4e28ba-2fa1-41d2-883f-0016d3cca427")
+
+// EventId returns a deterministic id for the event of eventType about subject.
+// An event that can only happen once per subject, such as an order being
+// requested for a basket, should use it in place of NewEvent's random id, so
+// however many times it is raised consumers see one message id.
+func EventId(eventType string, subject uuid.UUID) uuid.UUID {
+	return uuid.NewSHA1(eventNamespace, []byte(eventType+":"+subject.String()))
+}
+
+// Store is the read side of the outbox used by the Relay. The write side is
+// part of whichever store owns the state change, see
+// store.BasketStore.UpdateWithEvent.
+type Store interface {
+	// Pending returns up to limit unsent events, oldest first.
+	Pending(ctx context.Context, limit int) ([]Event, error)
+
+	// MarkSent removes an event from the pending set once it has been
+	// published.
+	MarkSent(ctx context.Context, id uuid.UUID) error
+
+	// Oldest returns when the oldest pending event was created, or false if
+	// nothing is pending.
+	Oldest(ctx context.Context) (time.Time, bool, error)
+}
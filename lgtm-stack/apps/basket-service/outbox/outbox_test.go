@@ -0,0 +1,17 @@
+package outbox
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventId(t *testing.T) {
+	basket := uuid.New()
+
+	id := EventId("order.requested", basket)
+	require.Equal(t, id, EventId("order.requested", basket))
+	require.NotEqual(t, id, EventId("order.requested", uuid.New()))
+	require.NotEqual(t, id, EventId("basket.abandoned", basket))
+}
@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"basket-service/telemetry"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const instrumentationName = "basket-service/outbox"
+
+// Publisher publishes a message and only returns nil once the broker has
+// confirmed it. rabbitmq.Manager satisfies it.
+type Publisher interface {
+	Publish(ctx context.Context, exchange, key string, msg amqp091.Publishing) error
+}
+
+type RelayOptions struct {
+	// Interval is how often the outbox is polled. Defaults to 1s.
+	Interval time.Duration
+
+	// BatchSize is the most events published per poll. Defaults to 100.
+	BatchSize int
+}
+
+// Relay publishes pending outbox events in the order they were written and
+// marks each one sent once the broker has confirmed it. Delivery is at least
+// once: an event published just before a crash is published again on restart,
+// so consumers should deduplicate on the message id.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	opts      RelayOptions
+
+	published metric.Int64Counter
+	failed    metric.Int64Counter
+}
+
+func NewRelay(store Store, publisher Publisher, opts RelayOptions) (*Relay, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	meter := otel.Meter(instrumentationName)
+
+	published, err := meter.Int64Counter("outbox.events.published",
+		metric.WithDescription("Outbox events published and confirmed by the broker"))
+	if err != nil {
+		return nil, err
+	}
+
+	failed, err := meter.Int64Counter("outbox.events.failed",
+		metric.WithDescription("Outbox events that failed to publish and will be retried"))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = meter.Float64ObservableGauge("outbox.lag",
+		metric.WithUnit("s"),
+		metric.WithDescription("Age of the oldest event waiting in the outbox"),
+		metric.WithFloat64Callback(func(ctx context.Context, o metric.Float64Observer) error {
+			oldest, ok, err := store.Oldest(ctx)
+			if err != nil {
+				return err
+			}
+
+			lag := 0.0
+			if ok {
+				lag = time.Since(oldest).Seconds()
+			}
+
+			o.Observe(lag)
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		opts:      opts,
+		published: published,
+		failed:    failed,
+	}, nil
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to relay outbox events", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes pending events, one batch at a time, until the outbox is
+// empty or an event fails to publish. Events after a failure are left for the
+// next attempt so that ordering is preserved.
+func (r *Relay) Flush(ctx context.Context) error {
+	for {
+		events, err := r.store.Pending(ctx, r.opts.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := r.publish(ctx, event); err != nil {
+				r.failed.Add(ctx, 1, metric.WithAttributes(attribute.String("event.type", event.Type)))
+				return err
+			}
+
+			if err := r.store.MarkSent(ctx, event.Id); err != nil {
+				return err
+			}
+
+			r.published.Add(ctx, 1, metric.WithAttributes(attribute.String("event.type", event.Type)))
+		}
+
+		if len(events) < r.opts.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event Event) error {
+	// continue the trace of the request that wrote the event
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(event.Headers))
+
+	msg := amqp091.Publishing{
+		ContentType: "application/json",
+		MessageId:   event.Id.String(),
+		Type:        event.Type,
+		Timestamp:   event.CreatedAt,
+		Body:        event.Body,
+	}
+
+	ctx, span := telemetry.StartPublishSpan(ctx, event.Exchange, event.RoutingKey, &msg)
+	err := r.publisher.Publish(ctx, event.Exchange, event.RoutingKey, msg)
+	telemetry.EndSpan(span, err)
+
+	return err
+}
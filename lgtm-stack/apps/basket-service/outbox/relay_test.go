@@ -0,0 +1,86 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+	"basket-service/store"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	published []amqp091.Publishing
+	err       error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, exchange, key string, msg amqp091.Publishing) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func checkout(t *testing.T, s store.BasketStore) outbox.Event {
+	ctx := context.Background()
+
+	basket := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	require.NoError(t, s.Create(ctx, &basket))
+
+	basket.Status = model.StatusCheckedOut
+	event, err := outbox.NewEvent(ctx, "order.requested", "", "orders", basket)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateWithEvent(ctx, &basket, event))
+
+	return event
+}
+
+func Test_Relay_PublishesPendingEventsInOrder(t *testing.T) {
+	s := store.NewMemoryStore(time.Hour)
+	first := checkout(t, s)
+	second := checkout(t, s)
+
+	publisher := &fakePublisher{}
+	relay, err := outbox.NewRelay(s, publisher, outbox.RelayOptions{BatchSize: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, relay.Flush(context.Background()))
+
+	require.Len(t, publisher.published, 2)
+	require.Equal(t, first.Id.String(), publisher.published[0].MessageId)
+	require.Equal(t, second.Id.String(), publisher.published[1].MessageId)
+	require.Equal(t, "order.requested", publisher.published[0].Type)
+	require.JSONEq(t, string(first.Body), string(publisher.published[0].Body))
+
+	_, ok, err := s.Oldest(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func Test_Relay_KeepsEventsThatFailToPublish(t *testing.T) {
+	s := store.NewMemoryStore(time.Hour)
+	event := checkout(t, s)
+
+	publisher := &fakePublisher{err: errors.New("broker unavailable")}
+	relay, err := outbox.NewRelay(s, publisher, outbox.RelayOptions{})
+	require.NoError(t, err)
+
+	require.Error(t, relay.Flush(context.Background()))
+
+	pending, err := s.Pending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, event.Id, pending[0].Id)
+
+	publisher.err = nil
+	require.NoError(t, relay.Flush(context.Background()))
+	require.Len(t, publisher.published, 1)
+}
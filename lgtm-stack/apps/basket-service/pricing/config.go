@@ -0,0 +1,121 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"basket-service/coupons"
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// Config is the engine's rules: tax and shipping per region, and the
+// promotions that are running, in the order they apply.
+type Config struct {
+	DefaultRegion string            `json:"defaultRegion"`
+	Regions       map[string]Region `json:"regions"`
+	Promotions    Promotions        `json:"promotions"`
+}
+
+// DefaultConfig prices UK baskets with VAT included and no promotions.
+func DefaultConfig(currency string) Config {
+	return Config{
+		DefaultRegion: "GB",
+		Regions: map[string]Region{
+			"GB": {
+				TaxRate:          money.MustParseRate("20%"),
+				TaxIncluded:      true,
+				Shipping:         money.MustParse("3.99", currency),
+				FreeShippingOver: money.MustParse("50.00", currency),
+			},
+		},
+	}
+}
+
+// LoadConfig reads a Config from a JSON file, e.g.
+//
+//	{
+//	  "defaultRegion": "GB",
+//	  "regions": {
+//	    "GB": {"taxRate": "20%", "taxIncluded": true, "shipping": {"amount": "3.99", "currency": "GBP"}}
+//	  },
+//	  "promotions": [
+//	    {"type": "percent_off", "id": "autumn", "description": "10% off everything", "rate": "10%"},
+//	    {"type": "buy_x_get_y", "id": "socks", "buy": 2, "get": 1, "products": ["..."]},
+//	    {"type": "threshold", "id": "spend-50", "over": {"amount": "50", "currency": "GBP"}, "amount": {"amount": "5", "currency": "GBP"}},
+//	    {"type": "percent_off", "id": "welcome", "coupon": "WELCOME10", "rate": "10%"}
+//	  ]
+//	}
+//
+// Any promotion with a coupon only applies to baskets with that code.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Promotions decodes a JSON list of promotions, picking the rule by each one's
+// "type".
+type Promotions []Promotion
+
+type promotionConfig struct {
+	Type        string      `json:"type"`
+	Id          string      `json:"id"`
+	Description string      `json:"description"`
+	Coupon      string      `json:"coupon"`
+	Products    []uuid.UUID `json:"products"`
+
+	Rate   money.Rate  `json:"rate"`
+	Buy    uint        `json:"buy"`
+	Get    uint        `json:"get"`
+	Over   money.Money `json:"over"`
+	Amount money.Money `json:"amount"`
+}
+
+func (p *Promotions) UnmarshalJSON(data []byte) error {
+	var configs []promotionConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return err
+	}
+
+	promotions := make(Promotions, 0, len(configs))
+	for i, config := range configs {
+		if config.Id == "" {
+			return fmt.Errorf("promotions[%d]: id is required", i)
+		}
+
+		var promotion Promotion
+		switch config.Type {
+		case "percent_off":
+			promotion = PercentOff{Id: config.Id, Description: config.Description, Rate: config.Rate, Products: config.Products}
+		case "buy_x_get_y":
+			if config.Buy < 1 || config.Get < 1 {
+				return fmt.Errorf("promotion %s: buy and get must be at least 1", config.Id)
+			}
+			promotion = BuyXGetY{Id: config.Id, Description: config.Description, Buy: config.Buy, Get: config.Get, Products: config.Products}
+		case "threshold":
+			promotion = Threshold{Id: config.Id, Description: config.Description, Over: config.Over, Amount: config.Amount, Rate: config.Rate}
+		default:
+			return fmt.Errorf("promotion %s: unknown type %q", config.Id, config.Type)
+		}
+
+		if config.Coupon != "" {
+			promotion = Coupon{Code: coupons.Normalize(config.Coupon), Promotion: promotion}
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	*p = promotions
+	return nil
+}
@@ -0,0 +1,66 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"basket-service/model"
+	"money-go"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"defaultRegion": "GB",
+		"regions": {
+			"GB": {
+				"taxRate": "20%",
+				"taxIncluded": true,
+				"shipping": {"amount": "3.99", "currency": "GBP"},
+				"freeShippingOver": {"amount": "50", "currency": "GBP"}
+			}
+		},
+		"promotions": [
+			{"type": "percent_off", "id": "autumn", "description": "10% off shirts", "rate": "10%", "products": ["72119506-89ef-4c0c-ace7-6cbd984bfc50"]},
+			{"type": "buy_x_get_y", "id": "socks", "buy": 2, "get": 1},
+			{"type": "threshold", "id": "spend-50", "over": {"amount": "50", "currency": "GBP"}, "amount": {"amount": "5", "currency": "GBP"}},
+			{"type": "percent_off", "id": "welcome", "coupon": "WELCOME10", "rate": "10%"}
+		]
+	}`), 0o644))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	require.Equal(t, "GB", config.DefaultRegion)
+	require.Equal(t, "0.2000", config.Regions["GB"].TaxRate.String())
+	require.Equal(t, gbp("3.99"), config.Regions["GB"].Shipping)
+
+	require.Equal(t, Promotions{
+		PercentOff{Id: "autumn", Description: "10% off shirts", Rate: money.MustParseRate("10%"), Products: []uuid.UUID{shirt}},
+		BuyXGetY{Id: "socks", Buy: 2, Get: 1},
+		Threshold{Id: "spend-50", Over: gbp("50"), Amount: gbp("5")},
+		Coupon{Code: "WELCOME10", Promotion: PercentOff{Id: "welcome", Rate: money.MustParseRate("10%")}},
+	}, config.Promotions)
+
+	_, err = New(model.Currency, config)
+	require.NoError(t, err)
+}
+
+func Test_LoadConfig_InvalidPromotions(t *testing.T) {
+	for name, promotions := range map[string]string{
+		"missing id":   `[{"type": "percent_off", "rate": "10%"}]`,
+		"unknown type": `[{"type": "bogof", "id": "x"}]`,
+		"buy nothing":  `[{"type": "buy_x_get_y", "id": "x", "buy": 0, "get": 1}]`,
+		"bad rate":     `[{"type": "percent_off", "id": "x", "rate": "lots"}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "pricing.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"defaultRegion": "GB", "promotions": `+promotions+`}`), 0o644))
+
+		_, err := LoadConfig(path)
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,368 @@
+// Package pricing works out what a basket costs: line totals, promotions,
+// shipping and tax.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"basket-service/model"
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownRegion is returned when a basket is priced for a region the engine
+// has no rules for.
+var ErrUnknownRegion = errors.New("unknown pricing region")
+
+// Breakdown is a priced basket. Every amount is in the engine's currency.
+type Breakdown struct {
+	Region    string     `json:"region"`
+	Lines     []Line     `json:"lines"`
+	Discounts []Discount `json:"discounts"`
+
+	Subtotal money.Money `json:"subtotal"`
+	Discount money.Money `json:"discount"`
+	Shipping money.Money `json:"shipping"`
+	Tax      money.Money `json:"tax"`
+
+	// TaxIncluded is set when Tax is already part of the item prices rather
+	// than added on top of them.
+	TaxIncluded bool        `json:"taxIncluded"`
+	Total       money.Money `json:"total"`
+}
+
+// Line is one basket item with its share of the discounts and tax.
+type Line struct {
+	ItemId    uuid.UUID   `json:"itemId"`
+	ProductId uuid.UUID   `json:"catalogId"`
+	UnitPrice money.Money `json:"unitPrice"`
+	Quantity  uint        `json:"quantity"`
+
+	// Total is UnitPrice × Quantity, Discount includes the line's share of
+	// basket-wide discounts and Tax is worked out on what is left.
+	Total    money.Money `json:"total"`
+	Discount money.Money `json:"discount"`
+	Tax      money.Money `json:"tax"`
+}
+
+// Net is what is left of the line once its discounts are taken off.
+func (l Line) Net() (money.Money, error) {
+	return l.Total.Sub(l.Discount)
+}
+
+// Discount is one promotion applied to the basket, either to a single line
+// (ItemId is set) or to the basket as a whole.
+type Discount struct {
+	Promotion   string      `json:"promotion"`
+	Description string      `json:"description"`
+	Coupon      string      `json:"coupon,omitempty"`
+	ItemId      *uuid.UUID  `json:"itemId,omitempty"`
+	Amount      money.Money `json:"amount"`
+}
+
+// Region holds the tax and shipping rules for where a basket is delivered.
+type Region struct {
+	TaxRate money.Rate `json:"taxRate"`
+
+	// TaxIncluded means prices already include tax at TaxRate, as UK retail
+	// prices include VAT, so tax is reported but not added to the total.
+	TaxIncluded bool `json:"taxIncluded"`
+
+	// Shipping is charged on every non-empty basket, unless what is left after
+	// discounts reaches FreeShippingOver. A zero FreeShippingOver never waives
+	// it. Shipping is not taxed.
+	Shipping         money.Money `json:"shipping"`
+	FreeShippingOver money.Money `json:"freeShippingOver"`
+}
+
+// Engine prices baskets. It is safe for concurrent use.
+type Engine struct {
+	currency      string
+	defaultRegion string
+	regions       map[string]Region
+	promotions    []Promotion
+}
+
+// New returns an engine for baskets priced in currency.
+func New(currency string, config Config) (*Engine, error) {
+	if _, ok := config.Regions[config.DefaultRegion]; !ok {
+		return nil, fmt.Errorf("%w: default region %q", ErrUnknownRegion, config.DefaultRegion)
+	}
+
+	for code, region := range config.Regions {
+		for _, amount := range []money.Money{region.Shipping, region.FreeShippingOver} {
+			if !amount.IsZero() && amount.Currency() != currency {
+				return nil, fmt.Errorf("region %s: %w: %s is not in %s", code, money.ErrCurrencyMismatch, amount, currency)
+			}
+		}
+	}
+
+	return &Engine{
+		currency:      currency,
+		defaultRegion: config.DefaultRegion,
+		regions:       config.Regions,
+		promotions:    config.Promotions,
+	}, nil
+}
+
+// HasRegion reports whether the engine can price baskets for region.
+func (e *Engine) HasRegion(region string) bool {
+	_, ok := e.regions[region]
+	return ok
+}
+
+// HasCoupon reports whether any promotion applies to baskets with code.
+func (e *Engine) HasCoupon(code string) bool {
+	for _, promotion := range e.promotions {
+		if coupon, ok := promotion.(Coupon); ok && coupon.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Price prices the basket for its region, or the default region if it has
+// none, with the coupon codes the customer has entered. Promotions are applied
+// in the order they were configured, each to what the ones before it left.
+func (e *Engine) Price(basket *model.Basket, coupons []string) (*Breakdown, error) {
+	code := basket.Region
+	if code == "" {
+		code = e.defaultRegion
+	}
+
+	region, ok := e.regions[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRegion, code)
+	}
+
+	quote := &Quote{Lines: make([]Line, 0, len(basket.Items)), Coupons: coupons}
+	for _, item := range basket.Items {
+		total, err := item.Price.Mul(int64(item.Quantity))
+		if err != nil {
+			return nil, err
+		}
+
+		quote.Lines = append(quote.Lines, Line{
+			ItemId:    item.Id,
+			ProductId: item.ProductId,
+			UnitPrice: item.Price,
+			Quantity:  item.Quantity,
+			Total:     total,
+			Discount:  money.New(0, e.currency),
+			Tax:       money.New(0, e.currency),
+		})
+	}
+
+	breakdown := &Breakdown{
+		Region:      code,
+		Discounts:   []Discount{},
+		TaxIncluded: region.TaxIncluded,
+		Subtotal:    money.New(0, e.currency),
+		Discount:    money.New(0, e.currency),
+		Shipping:    money.New(0, e.currency),
+		Tax:         money.New(0, e.currency),
+		Total:       money.New(0, e.currency),
+	}
+
+	for _, promotion := range e.promotions {
+		discounts, err := promotion.Apply(quote)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, discount := range discounts {
+			applied, err := quote.apply(discount)
+			if err != nil {
+				return nil, err
+			}
+
+			if !applied.Amount.IsZero() {
+				breakdown.Discounts = append(breakdown.Discounts, applied)
+			}
+		}
+	}
+
+	for i := range quote.Lines {
+		line := &quote.Lines[i]
+
+		net, err := line.Net()
+		if err != nil {
+			return nil, err
+		}
+
+		if region.TaxIncluded {
+			line.Tax, err = net.IncludedTax(region.TaxRate)
+		} else {
+			line.Tax, err = net.Tax(region.TaxRate)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sum(&breakdown.Subtotal, line.Total); err != nil {
+			return nil, err
+		}
+		if err := sum(&breakdown.Discount, line.Discount); err != nil {
+			return nil, err
+		}
+		if err := sum(&breakdown.Tax, line.Tax); err != nil {
+			return nil, err
+		}
+	}
+
+	net, err := quote.Net()
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := net.Cmp(region.FreeShippingOver.WithCurrency(e.currency))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quote.Lines) > 0 && (region.FreeShippingOver.IsZero() || free < 0) {
+		breakdown.Shipping = region.Shipping.WithCurrency(e.currency)
+	}
+
+	if err := sum(&breakdown.Total, net); err != nil {
+		return nil, err
+	}
+	if err := sum(&breakdown.Total, breakdown.Shipping); err != nil {
+		return nil, err
+	}
+	if !region.TaxIncluded {
+		if err := sum(&breakdown.Total, breakdown.Tax); err != nil {
+			return nil, err
+		}
+	}
+
+	breakdown.Lines = quote.Lines
+	return breakdown, nil
+}
+
+// Quote is a basket part way through being priced, as promotions see it.
+type Quote struct {
+	Lines []Line
+
+	// Coupons are the codes the customer has entered.
+	Coupons []string
+}
+
+// Net is what is left of the basket once the discounts so far are taken off.
+func (q *Quote) Net() (money.Money, error) {
+	var net money.Money
+	for _, line := range q.Lines {
+		lineNet, err := line.Net()
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		if err := sum(&net, lineNet); err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	return net, nil
+}
+
+// HasCoupon reports whether the customer has entered code.
+func (q *Quote) HasCoupon(code string) bool {
+	return slices.Contains(q.Coupons, code)
+}
+
+// apply takes a discount off its line, or spreads it over every line in
+// proportion to what is left of them. A discount is capped at what is left, so
+// nothing is ever priced below zero, and the discount is returned as applied.
+func (q *Quote) apply(discount Discount) (Discount, error) {
+	if discount.Amount.IsNegative() {
+		return Discount{}, fmt.Errorf("promotion %s: negative discount %s", discount.Promotion, discount.Amount)
+	}
+
+	if discount.ItemId != nil {
+		for i := range q.Lines {
+			line := &q.Lines[i]
+			if line.ItemId != *discount.ItemId {
+				continue
+			}
+
+			net, err := line.Net()
+			if err != nil {
+				return Discount{}, err
+			}
+
+			if discount.Amount, err = capped(discount.Amount, net); err != nil {
+				return Discount{}, err
+			}
+
+			return discount, sum(&line.Discount, discount.Amount)
+		}
+
+		return Discount{}, fmt.Errorf("promotion %s: no line for item %s", discount.Promotion, discount.ItemId)
+	}
+
+	net, err := q.Net()
+	if err != nil {
+		return Discount{}, err
+	}
+
+	if discount.Amount, err = capped(discount.Amount, net); err != nil {
+		return Discount{}, err
+	}
+
+	if discount.Amount.IsZero() {
+		return discount, nil
+	}
+
+	// spreading the discount over the lines gives each line's tax the right
+	// base
+	ratios := make([]int64, len(q.Lines))
+	for i, line := range q.Lines {
+		lineNet, err := line.Net()
+		if err != nil {
+			return Discount{}, err
+		}
+		ratios[i] = lineNet.Minor()
+	}
+
+	shares, err := discount.Amount.Allocate(ratios...)
+	if err != nil {
+		return Discount{}, err
+	}
+
+	for i, share := range shares {
+		if err := sum(&q.Lines[i].Discount, share); err != nil {
+			return Discount{}, err
+		}
+	}
+
+	return discount, nil
+}
+
+// capped returns amount, or limit if amount is more than it.
+func capped(amount, limit money.Money) (money.Money, error) {
+	c, err := amount.Cmp(limit)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if c > 0 {
+		return limit, nil
+	}
+
+	return amount, nil
+}
+
+// sum adds amount to total in place.
+func sum(total *money.Money, amount money.Money) error {
+	added, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	*total = added
+	return nil
+}
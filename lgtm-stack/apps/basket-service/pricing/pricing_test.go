@@ -0,0 +1,226 @@
+package pricing
+
+import (
+	"testing"
+
+	"basket-service/model"
+	"money-go"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	shirt = uuid.MustParse("72119506-89ef-4c0c-ace7-6cbd984bfc50")
+	socks = uuid.MustParse("5d3b9a3c-1f2e-4b6a-8c7d-9e0f1a2b3c4d")
+)
+
+func gbp(amount string) money.Money {
+	return money.MustParse(amount, model.Currency)
+}
+
+func basket(items ...model.BasketItem) *model.Basket {
+	for i := range items {
+		items[i].Id = uuid.New()
+	}
+
+	return &model.Basket{Id: uuid.New(), Items: items}
+}
+
+func newEngine(t *testing.T, promotions ...Promotion) *Engine {
+	config := DefaultConfig(model.Currency)
+	config.Regions["US-NY"] = Region{TaxRate: money.MustParseRate("8.875%"), Shipping: gbp("9.99")}
+	config.Promotions = promotions
+
+	engine, err := New(model.Currency, config)
+	require.NoError(t, err)
+
+	return engine
+}
+
+func Test_Engine_Price_NoPromotions(t *testing.T) {
+	b := basket(
+		model.BasketItem{ProductId: shirt, Price: gbp("25.00"), Quantity: 1},
+		model.BasketItem{ProductId: socks, Price: gbp("3.33"), Quantity: 3},
+	)
+
+	breakdown, err := newEngine(t).Price(b, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "GB", breakdown.Region)
+	require.Len(t, breakdown.Lines, 2)
+	require.Equal(t, gbp("9.99"), breakdown.Lines[1].Total)
+	require.Equal(t, gbp("34.99"), breakdown.Subtotal)
+	require.Equal(t, gbp("0"), breakdown.Discount)
+	require.Equal(t, gbp("3.99"), breakdown.Shipping)
+
+	// VAT is included in the prices: 25.00 / 6 = 4.1666..., 9.99 / 6 = 1.665
+	require.True(t, breakdown.TaxIncluded)
+	require.Equal(t, gbp("4.17"), breakdown.Lines[0].Tax)
+	require.Equal(t, gbp("1.66"), breakdown.Lines[1].Tax)
+	require.Equal(t, gbp("5.83"), breakdown.Tax)
+	require.Equal(t, gbp("38.98"), breakdown.Total)
+}
+
+func Test_Engine_Price_TaxOnTop(t *testing.T) {
+	b := basket(model.BasketItem{ProductId: shirt, Price: gbp("100.00"), Quantity: 1})
+	b.Region = "US-NY"
+
+	breakdown, err := newEngine(t).Price(b, nil)
+	require.NoError(t, err)
+
+	require.False(t, breakdown.TaxIncluded)
+	require.Equal(t, gbp("8.88"), breakdown.Tax)
+	require.Equal(t, gbp("9.99"), breakdown.Shipping)
+	require.Equal(t, gbp("118.87"), breakdown.Total)
+}
+
+func Test_Engine_Price_Promotions(t *testing.T) {
+	tests := []struct {
+		name       string
+		promotions []Promotion
+		coupons    []string
+		discount   string
+		shipping   string
+		total      string
+	}{
+		{
+			name:       "percent off one product",
+			promotions: []Promotion{PercentOff{Id: "shirts", Rate: money.MustParseRate("10%"), Products: []uuid.UUID{shirt}}},
+			discount:   "4.00",
+			shipping:   "3.99",
+			total:      "46.98",
+		},
+		{
+			name:       "percent off everything",
+			promotions: []Promotion{PercentOff{Id: "all", Rate: money.MustParseRate("10%")}},
+			discount:   "4.70",
+			shipping:   "3.99",
+			total:      "46.28",
+		},
+		{
+			name:       "buy 2 get 1 free",
+			promotions: []Promotion{BuyXGetY{Id: "socks", Buy: 2, Get: 1, Products: []uuid.UUID{socks}}},
+			discount:   "2.33",
+			shipping:   "3.99",
+			total:      "48.65",
+		},
+		{
+			name:       "threshold reached",
+			promotions: []Promotion{Threshold{Id: "spend-40", Over: gbp("40"), Amount: gbp("5")}},
+			discount:   "5.00",
+			shipping:   "3.99",
+			total:      "45.98",
+		},
+		{
+			name: "threshold missed after an earlier discount",
+			promotions: []Promotion{
+				PercentOff{Id: "all", Rate: money.MustParseRate("20%")},
+				Threshold{Id: "spend-40", Over: gbp("40"), Amount: gbp("5")},
+			},
+			discount: "9.40",
+			shipping: "3.99",
+			total:    "41.58",
+		},
+		{
+			name:       "coupon not entered",
+			promotions: []Promotion{Coupon{Code: "WELCOME10", Promotion: PercentOff{Id: "welcome", Rate: money.MustParseRate("10%")}}},
+			discount:   "0",
+			shipping:   "3.99",
+			total:      "50.98",
+		},
+		{
+			name:       "coupon entered",
+			promotions: []Promotion{Coupon{Code: "WELCOME10", Promotion: PercentOff{Id: "welcome", Rate: money.MustParseRate("10%")}}},
+			coupons:    []string{"WELCOME10"},
+			discount:   "4.70",
+			shipping:   "3.99",
+			total:      "46.28",
+		},
+		{
+			name:       "discount capped at the basket",
+			promotions: []Promotion{Threshold{Id: "huge", Over: gbp("0"), Amount: gbp("1000")}},
+			discount:   "46.99",
+			shipping:   "3.99",
+			total:      "3.99",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// 40.00 of shirts and 6.99 of socks, just under free shipping
+			b := basket(
+				model.BasketItem{ProductId: shirt, Price: gbp("20.00"), Quantity: 2},
+				model.BasketItem{ProductId: socks, Price: gbp("2.33"), Quantity: 3},
+			)
+
+			breakdown, err := newEngine(t, test.promotions...).Price(b, test.coupons)
+			require.NoError(t, err)
+
+			require.Equal(t, gbp("46.99"), breakdown.Subtotal)
+			require.Equal(t, gbp(test.discount), breakdown.Discount)
+			require.Equal(t, gbp(test.shipping), breakdown.Shipping)
+			require.Equal(t, gbp(test.total), breakdown.Total)
+
+			// however a discount is applied, the lines account for all of it
+			var lines money.Money
+			for _, line := range breakdown.Lines {
+				lines, err = lines.Add(line.Discount)
+				require.NoError(t, err)
+				require.False(t, line.Discount.IsNegative())
+			}
+			require.Equal(t, breakdown.Discount, lines.WithCurrency(model.Currency))
+
+			var discounts money.Money
+			for _, discount := range breakdown.Discounts {
+				discounts, err = discounts.Add(discount.Amount)
+				require.NoError(t, err)
+			}
+			require.Equal(t, breakdown.Discount, discounts.WithCurrency(model.Currency))
+		})
+	}
+}
+
+func Test_Engine_Price_Discounts(t *testing.T) {
+	b := basket(model.BasketItem{ProductId: shirt, Price: gbp("60.00"), Quantity: 1})
+
+	engine := newEngine(t, Coupon{Code: "WELCOME10", Promotion: PercentOff{Id: "welcome", Description: "10% off", Rate: money.MustParseRate("10%")}})
+	breakdown, err := engine.Price(b, []string{"WELCOME10"})
+	require.NoError(t, err)
+
+	itemId := b.Items[0].Id
+	require.Equal(t, []Discount{{Promotion: "welcome", Description: "10% off", Coupon: "WELCOME10", ItemId: &itemId, Amount: gbp("6.00")}}, breakdown.Discounts)
+
+	// 54.00 is over the free shipping threshold, and VAT is worked out on it
+	require.Equal(t, gbp("0"), breakdown.Shipping)
+	require.Equal(t, gbp("9.00"), breakdown.Tax)
+	require.Equal(t, gbp("54.00"), breakdown.Total)
+}
+
+func Test_Engine_Price_Empty(t *testing.T) {
+	breakdown, err := newEngine(t, Threshold{Id: "free", Over: gbp("0"), Amount: gbp("5")}).Price(basket(), nil)
+	require.NoError(t, err)
+
+	require.Empty(t, breakdown.Lines)
+	require.Empty(t, breakdown.Discounts)
+	require.Equal(t, gbp("0"), breakdown.Shipping)
+	require.Equal(t, gbp("0"), breakdown.Total)
+}
+
+func Test_Engine_Price_UnknownRegion(t *testing.T) {
+	b := basket()
+	b.Region = "FR"
+
+	_, err := newEngine(t).Price(b, nil)
+	require.ErrorIs(t, err, ErrUnknownRegion)
+}
+
+func Test_New_Invalid(t *testing.T) {
+	config := DefaultConfig(model.Currency)
+	config.DefaultRegion = "FR"
+	_, err := New(model.Currency, config)
+	require.ErrorIs(t, err, ErrUnknownRegion)
+
+	_, err = New("EUR", DefaultConfig(model.Currency))
+	require.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
@@ -0,0 +1,157 @@
+package pricing
+
+import (
+	"slices"
+
+	"money-go"
+
+	"github.com/google/uuid"
+)
+
+// Promotion works out the discounts a basket is entitled to. It sees the
+// basket as the promotions before it left it, and the discounts it returns are
+// capped at what is left of the line or basket they apply to.
+type Promotion interface {
+	Apply(quote *Quote) ([]Discount, error)
+}
+
+// PercentOff takes Rate off every line for one of Products, or off every line
+// if Products is empty.
+type PercentOff struct {
+	Id          string
+	Description string
+	Rate        money.Rate
+	Products    []uuid.UUID
+}
+
+func (p PercentOff) Apply(quote *Quote) ([]Discount, error) {
+	var discounts []Discount
+	for _, line := range quote.Lines {
+		if !matches(p.Products, line.ProductId) {
+			continue
+		}
+
+		net, err := line.Net()
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := net.MulRate(p.Rate)
+		if err != nil {
+			return nil, err
+		}
+
+		discounts = append(discounts, lineDiscount(p.Id, p.Description, line, amount))
+	}
+
+	return discounts, nil
+}
+
+// BuyXGetY makes Get units free for every Buy + Get units of the same product,
+// e.g. Buy 2 Get 1 is three for the price of two. Products limits it the same
+// way as PercentOff.
+type BuyXGetY struct {
+	Id          string
+	Description string
+	Buy         uint
+	Get         uint
+	Products    []uuid.UUID
+}
+
+func (p BuyXGetY) Apply(quote *Quote) ([]Discount, error) {
+	if p.Get == 0 {
+		return nil, nil
+	}
+
+	var discounts []Discount
+	for _, line := range quote.Lines {
+		if !matches(p.Products, line.ProductId) {
+			continue
+		}
+
+		free := line.Quantity / (p.Buy + p.Get) * p.Get
+		if free == 0 {
+			continue
+		}
+
+		amount, err := line.UnitPrice.Mul(int64(free))
+		if err != nil {
+			return nil, err
+		}
+
+		discounts = append(discounts, lineDiscount(p.Id, p.Description, line, amount))
+	}
+
+	return discounts, nil
+}
+
+// Threshold takes Amount, or Rate of what is left, off the whole basket once
+// what is left reaches Over, e.g. £10 off when you spend £50.
+type Threshold struct {
+	Id          string
+	Description string
+	Over        money.Money
+	Amount      money.Money
+	Rate        money.Rate
+}
+
+func (p Threshold) Apply(quote *Quote) ([]Discount, error) {
+	net, err := quote.Net()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quote.Lines) == 0 {
+		return nil, nil
+	}
+
+	c, err := net.Cmp(p.Over)
+	if err != nil {
+		return nil, err
+	}
+
+	if c < 0 {
+		return nil, nil
+	}
+
+	amount := p.Amount
+	if amount.IsZero() {
+		if amount, err = net.MulRate(p.Rate); err != nil {
+			return nil, err
+		}
+	}
+
+	return []Discount{{Promotion: p.Id, Description: p.Description, Amount: amount}}, nil
+}
+
+// Coupon applies Promotion only to baskets the customer has entered Code for.
+type Coupon struct {
+	Code      string
+	Promotion Promotion
+}
+
+func (p Coupon) Apply(quote *Quote) ([]Discount, error) {
+	if !quote.HasCoupon(p.Code) {
+		return nil, nil
+	}
+
+	discounts, err := p.Promotion.Apply(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range discounts {
+		discounts[i].Coupon = p.Code
+	}
+
+	return discounts, nil
+}
+
+func matches(products []uuid.UUID, productId uuid.UUID) bool {
+	return len(products) == 0 || slices.Contains(products, productId)
+}
+
+func lineDiscount(promotion, description string, line Line, amount money.Money) Discount {
+	itemId := line.ItemId
+	return Discount{Promotion: promotion, Description: description, ItemId: &itemId, Amount: amount}
+}
@@ -0,0 +1,61 @@
+package products
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type cacheEntry struct {
+	product   Product
+	expiresAt time.Time
+}
+
+// cache holds recently fetched products for a short time. Expired entries are
+// overwritten on the next fetch rather than swept.
+type cache struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	products map[uuid.UUID]cacheEntry
+
+	// now is swapped out in tests to control expiry.
+	now func() time.Time
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:      ttl,
+		products: make(map[uuid.UUID]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+func (c *cache) get(id uuid.UUID) (Product, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.products[id]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return Product{}, false
+	}
+
+	return entry.product, true
+}
+
+func (c *cache) set(id uuid.UUID, product Product) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.products[id] = cacheEntry{
+		product:   product,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+func (c *cache) delete(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.products, id)
+}
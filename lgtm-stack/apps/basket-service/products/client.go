@@ -0,0 +1,541 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"money-go"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/errgroup"
+)
+
+const instrumentationName = "basket-service/products"
+
+var (
+	// ErrNotFound is returned when product-service has no product with the
+	// requested id.
+	ErrNotFound = errors.New("products: product not found")
+
+	// ErrCircuitOpen is returned without calling product-service while the
+	// circuit breaker is open.
+	ErrCircuitOpen = errors.New("products: circuit breaker is open")
+
+	// ErrInvalidResponse is returned when a product-service response cannot be
+	// decoded. It is not retried.
+	ErrInvalidResponse = errors.New("products: invalid response from product-service")
+)
+
+// MissingError is returned by GetMany when some of the requested products do
+// not exist. It matches ErrNotFound.
+type MissingError struct {
+	Ids []uuid.UUID
+}
+
+func (e *MissingError) Error() string {
+	ids := make([]string, len(e.Ids))
+	for i, id := range e.Ids {
+		ids[i] = id.String()
+	}
+
+	return fmt.Sprintf("products: products not found: %s", strings.Join(ids, ", "))
+}
+
+func (e *MissingError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// errBatchUnsupported is returned when product-service does not have the
+// batch endpoint, GetMany then falls back to single lookups.
+var errBatchUnsupported = errors.New("products: batch lookup not supported")
+
+// StatusError is returned when product-service responds with a status the
+// client does not understand.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("products: unexpected status %d from product-service", e.StatusCode)
+}
+
+type Product struct {
+	Id          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+}
+
+// productResponse is a product as product-service sends it. The price is kept
+// as the literal JSON number so that it converts to Money exactly.
+type productResponse struct {
+	Id          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       json.Number `json:"price"`
+}
+
+func (r productResponse) product(currency string) (Product, error) {
+	price, err := money.Parse(r.Price.String(), currency)
+	if err != nil {
+		return Product{}, fmt.Errorf("%w: product %s: %w", ErrInvalidResponse, r.Id, err)
+	}
+
+	return Product{
+		Id:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       price,
+	}, nil
+}
+
+type Options struct {
+	// Timeout bounds each attempt, not the call as a whole. Defaults to 2s.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a request that failed with a network error
+	// or 5xx is retried. Defaults to 2.
+	MaxRetries int
+
+	// BaseBackoff is the delay before the first retry; it doubles for every
+	// retry after that and is jittered. Defaults to 100ms.
+	BaseBackoff time.Duration
+
+	// BreakerThreshold is how many consecutive failed calls open the circuit
+	// breaker, and BreakerCooldown how long it stays open before a trial call
+	// is let through. Default to 5 and 30s.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// CacheTTL is how long a product is served from memory. Prices are only
+	// cached briefly so that changes show up quickly. Defaults to 30s.
+	CacheTTL time.Duration
+
+	// BatchSize is the most ids GetMany sends in one batch request. Defaults
+	// to 100, the most product-service accepts.
+	BatchSize int
+
+	// Currency is the currency product-service prices are in. Defaults to GBP.
+	Currency string
+
+	// MaxConcurrency caps the single lookups GetMany has in flight when
+	// product-service has no batch endpoint. Defaults to 8.
+	MaxConcurrency int
+}
+
+// Client is a typed client for product-service's /api/products endpoints.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	opts    Options
+
+	breaker *breaker
+	cache   *cache
+
+	// duration records how long each call to product-service took, retries
+	// included.
+	duration metric.Float64Histogram
+
+	// noBatch is set the first time product-service turns out not to have the
+	// batch endpoint, so it is not asked again until the service restarts.
+	noBatch atomic.Bool
+}
+
+// New returns a client for the product-service at baseURL. httpClient is used
+// for every request, pass one with an instrumented transport to trace them.
+func New(baseURL string, httpClient *http.Client, opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = 2
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 100 * time.Millisecond
+	}
+	if opts.BreakerThreshold <= 0 {
+		opts.BreakerThreshold = 5
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = 30 * time.Second
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 30 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Currency == "" {
+		opts.Currency = "GBP"
+	}
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = 8
+	}
+
+	// on error the instrument is a no-op one, and a client that cannot record
+	// its latency is still a working client
+	duration, err := otel.Meter(instrumentationName).Float64Histogram("product_service.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of calls to product-service by operation and outcome, retries included"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &Client{
+		baseURL:  baseURL,
+		http:     httpClient,
+		opts:     opts,
+		breaker:  newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		cache:    newCache(opts.CacheTTL),
+		duration: duration,
+	}
+}
+
+// Get returns the product with the given id.
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (Product, error) {
+	if product, ok := c.cache.get(id); ok {
+		return product, nil
+	}
+
+	return c.load(ctx, id)
+}
+
+// load fetches a product from product-service, skipping the cache, and caches
+// it.
+func (c *Client) load(ctx context.Context, id uuid.UUID) (Product, error) {
+	var product Product
+	err := c.call(ctx, "get", func(ctx context.Context) (err error) {
+		product, err = c.get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return Product{}, err
+	}
+
+	c.cache.set(id, product)
+	return product, nil
+}
+
+// Ping checks that product-service is answering by looking up a product that
+// does not exist. It skips the cache, retries and circuit breaker, so it says
+// how product-service is doing right now.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	_, err := c.get(ctx, uuid.Nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// GetMany returns the products with the given ids, keyed by id. Products that
+// are not cached are fetched with a single batch request, or with concurrent
+// single lookups if product-service has no batch endpoint. If any product does
+// not exist the error is a *MissingError listing all of them, and the products
+// that do exist are still returned.
+func (c *Client) GetMany(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]Product, error) {
+	return c.getMany(ctx, ids, true)
+}
+
+// Refresh is GetMany without the cache: every product is fetched from
+// product-service, and the cache updated with what comes back, including
+// forgetting products that no longer exist. It is for when
+// a stale price will not do, such as at checkout.
+func (c *Client) Refresh(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]Product, error) {
+	return c.getMany(ctx, ids, false)
+}
+
+func (c *Client) getMany(ctx context.Context, ids []uuid.UUID, cached bool) (map[uuid.UUID]Product, error) {
+	products := make(map[uuid.UUID]Product, len(ids))
+
+	var uncached []uuid.UUID
+	for _, id := range ids {
+		if _, ok := products[id]; ok || slices.Contains(uncached, id) {
+			continue
+		}
+
+		if product, ok := c.cache.get(id); ok && cached {
+			products[id] = product
+		} else {
+			uncached = append(uncached, id)
+		}
+	}
+
+	if len(uncached) > 0 {
+		found, err := c.fetch(ctx, uncached)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range found {
+			c.cache.set(product.Id, product)
+			products[product.Id] = product
+		}
+	}
+
+	var missing []uuid.UUID
+	for _, id := range uncached {
+		if _, ok := products[id]; !ok {
+			missing = append(missing, id)
+			c.cache.delete(id)
+		}
+	}
+
+	if len(missing) > 0 {
+		return products, &MissingError{Ids: missing}
+	}
+
+	return products, nil
+}
+
+// fetch returns whichever of ids exist.
+func (c *Client) fetch(ctx context.Context, ids []uuid.UUID) ([]Product, error) {
+	if !c.noBatch.Load() {
+		products, err := c.fetchBatches(ctx, ids)
+		if !errors.Is(err, errBatchUnsupported) {
+			return products, err
+		}
+
+		c.noBatch.Store(true)
+	}
+
+	return c.fetchEach(ctx, ids)
+}
+
+func (c *Client) fetchBatches(ctx context.Context, ids []uuid.UUID) ([]Product, error) {
+	var products []Product
+	for start := 0; start < len(ids); start += c.opts.BatchSize {
+		batch := ids[start:min(start+c.opts.BatchSize, len(ids))]
+		err := c.call(ctx, "batch", func(ctx context.Context) error {
+			found, err := c.getBatch(ctx, batch)
+			products = append(products, found...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return products, nil
+}
+
+func (c *Client) fetchEach(ctx context.Context, ids []uuid.UUID) ([]Product, error) {
+	results := make([]*Product, len(ids))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.opts.MaxConcurrency)
+
+	for i, id := range ids {
+		g.Go(func() error {
+			product, err := c.load(ctx, id)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, id)
+			}
+
+			results[i] = &product
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var products []Product
+	for _, product := range results {
+		if product != nil {
+			products = append(products, *product)
+		}
+	}
+
+	return products, nil
+}
+
+// call runs fn through the circuit breaker, retrying it on network errors,
+// timeouts and 5xx responses, and records how long the operation took.
+func (c *Client) call(ctx context.Context, operation string, fn func(ctx context.Context) error) (err error) {
+	start := time.Now()
+	defer func() {
+		c.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("operation", operation),
+			attribute.String("outcome", outcome(err)),
+		))
+	}()
+
+	if !c.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	err = c.retry(ctx, fn)
+	if err != nil && retryable(err) {
+		c.breaker.failure()
+	} else {
+		c.breaker.success()
+	}
+
+	return err
+}
+
+// outcome names how a call ended, for the latency metric.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, errBatchUnsupported):
+		return "unsupported"
+	default:
+		return "error"
+	}
+}
+
+func (c *Client) retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.backoff(attempt)):
+			}
+		}
+
+		err = c.attempt(ctx, fn)
+		if err == nil || !retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// attempt runs fn with the per-attempt timeout.
+func (c *Client) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	return fn(ctx)
+}
+
+func (c *Client) get(ctx context.Context, id uuid.UUID) (Product, error) {
+	uri := fmt.Sprintf("%s/api/products/%s", c.baseURL, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return Product{}, err
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return Product{}, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+	case res.StatusCode == http.StatusNotFound:
+		return Product{}, ErrNotFound
+	default:
+		// drain so the connection can be reused
+		io.Copy(io.Discard, res.Body)
+		return Product{}, &StatusError{StatusCode: res.StatusCode}
+	}
+
+	var product productResponse
+	if err := json.NewDecoder(res.Body).Decode(&product); err != nil {
+		return Product{}, fmt.Errorf("%w: decoding product %s: %w", ErrInvalidResponse, id, err)
+	}
+
+	return product.product(c.opts.Currency)
+}
+
+func (c *Client) getBatch(ctx context.Context, ids []uuid.UUID) ([]Product, error) {
+	body, err := json.Marshal(map[string][]uuid.UUID{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("%s/api/products/batch", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		io.Copy(io.Discard, res.Body)
+		return nil, errBatchUnsupported
+	default:
+		io.Copy(io.Discard, res.Body)
+		return nil, &StatusError{StatusCode: res.StatusCode}
+	}
+
+	var responses []productResponse
+	if err := json.NewDecoder(res.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("%w: decoding product batch: %w", ErrInvalidResponse, err)
+	}
+
+	products := make([]Product, len(responses))
+	for i, response := range responses {
+		if products[i], err = response.product(c.opts.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	return products, nil
+}
+
+// backoff returns the jittered delay before retry n (n >= 1).
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.opts.BaseBackoff << (attempt - 1)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryable reports whether err is worth retrying and counts against the
+// circuit breaker: network errors, timeouts and 5xx responses.
+func retryable(err error) bool {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidResponse) ||
+		errors.Is(err, errBatchUnsupported) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+
+	return true
+}
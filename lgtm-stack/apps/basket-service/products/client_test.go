@@ -0,0 +1,397 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"money-go"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// productService is an httptest stand-in for product-service. status decides
+// the response for each call; returning 200 serves the product from catalog.
+// The batch endpoint is only served when batch is set.
+type productService struct {
+	catalog map[uuid.UUID]Product
+	status  func(call int64) int
+	delay   time.Duration
+	batch   bool
+
+	calls        atomic.Int64
+	batchCalls   atomic.Int64
+	maxBatchSize atomic.Int64
+}
+
+func (p *productService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := p.calls.Add(1)
+
+	if p.delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(p.delay):
+		}
+	}
+
+	if p.status != nil {
+		if status := p.status(call); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	if r.URL.Path == "/api/products/batch" {
+		p.serveBatch(w, r)
+		return
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(r.URL.Path, "/api/products/"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	product, ok := p.catalog[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(wire(product))
+}
+
+func (p *productService) serveBatch(w http.ResponseWriter, r *http.Request) {
+	if !p.batch || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.batchCalls.Add(1)
+
+	var request struct {
+		Ids []uuid.UUID `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if size := int64(len(request.Ids)); size > p.maxBatchSize.Load() {
+		p.maxBatchSize.Store(size)
+	}
+
+	products := []map[string]any{}
+	for _, id := range request.Ids {
+		if product, ok := p.catalog[id]; ok {
+			products = append(products, wire(product))
+		}
+	}
+
+	json.NewEncoder(w).Encode(products)
+}
+
+// wire returns product as product-service encodes it, with the price as a
+// plain JSON number.
+func wire(product Product) map[string]any {
+	return map[string]any{
+		"id":          product.Id,
+		"name":        product.Name,
+		"description": product.Description,
+		"price":       json.Number(product.Price.Amount()),
+	}
+}
+
+func newTestClient(t *testing.T, service http.Handler, opts Options) *Client {
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+
+	if opts.BaseBackoff == 0 {
+		opts.BaseBackoff = time.Millisecond
+	}
+
+	return New(server.URL, server.Client(), opts)
+}
+
+func Test_Client_Get(t *testing.T) {
+	product := Product{Id: uuid.New(), Name: "Mug", Price: money.MustParse("4.99", "GBP")}
+	service := &productService{catalog: map[uuid.UUID]Product{product.Id: product}}
+	client := newTestClient(t, service, Options{})
+
+	got, err := client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+	require.Equal(t, product, got)
+
+	// served from the cache
+	got, err = client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+	require.Equal(t, product, got)
+	require.EqualValues(t, 1, service.calls.Load())
+
+	// refetched once the cache entry has expired
+	client.cache.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, err = client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, service.calls.Load())
+}
+
+func Test_Client_Get_NotFound(t *testing.T) {
+	service := &productService{}
+	client := newTestClient(t, service, Options{})
+
+	_, err := client.Get(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualValues(t, 1, service.calls.Load(), "a 404 is not retried")
+}
+
+func Test_Client_Get_RetriesServerErrors(t *testing.T) {
+	product := Product{Id: uuid.New(), Price: money.MustParse("1", "GBP")}
+	service := &productService{
+		catalog: map[uuid.UUID]Product{product.Id: product},
+		status: func(call int64) int {
+			if call < 3 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		},
+	}
+	client := newTestClient(t, service, Options{MaxRetries: 2})
+
+	got, err := client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+	require.Equal(t, product, got)
+	require.EqualValues(t, 3, service.calls.Load())
+}
+
+func Test_Client_Get_GivesUpAfterMaxRetries(t *testing.T) {
+	service := &productService{status: func(int64) int { return http.StatusInternalServerError }}
+	client := newTestClient(t, service, Options{MaxRetries: 2})
+
+	_, err := client.Get(context.Background(), uuid.New())
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	require.EqualValues(t, 3, service.calls.Load())
+}
+
+func Test_Client_Get_DoesNotRetryClientErrors(t *testing.T) {
+	service := &productService{status: func(int64) int { return http.StatusBadRequest }}
+	client := newTestClient(t, service, Options{})
+
+	_, err := client.Get(context.Background(), uuid.New())
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.EqualValues(t, 1, service.calls.Load())
+}
+
+func Test_Client_Get_Timeout(t *testing.T) {
+	service := &productService{delay: time.Second}
+	client := newTestClient(t, service, Options{Timeout: 20 * time.Millisecond, MaxRetries: 1})
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), uuid.New())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.EqualValues(t, 2, service.calls.Load())
+}
+
+func Test_Client_Get_BadJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"price": "not a number"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, server.Client(), Options{})
+
+	_, err := client.Get(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func Test_Client_Get_ExactPrice(t *testing.T) {
+	id := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": %q, "name": "Mug", "price": 25360.05}`, id)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, server.Client(), Options{Currency: "GBP"})
+
+	product, err := client.Get(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, money.New(2536005, "GBP"), product.Price)
+}
+
+func Test_Client_CircuitBreaker(t *testing.T) {
+	product := Product{Id: uuid.New(), Price: money.MustParse("1", "GBP")}
+	healthy := atomic.Bool{}
+	service := &productService{
+		catalog: map[uuid.UUID]Product{product.Id: product},
+		status: func(int64) int {
+			if healthy.Load() {
+				return http.StatusOK
+			}
+			return http.StatusBadGateway
+		},
+	}
+	client := newTestClient(t, service, Options{MaxRetries: -1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	for range 2 {
+		_, err := client.Get(context.Background(), product.Id)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+	}
+
+	// open: product-service is not called
+	_, err := client.Get(context.Background(), product.Id)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.EqualValues(t, 2, service.calls.Load())
+
+	// after the cooldown a failed trial call reopens it
+	now = now.Add(time.Minute)
+	_, err = client.Get(context.Background(), product.Id)
+	require.NotErrorIs(t, err, ErrCircuitOpen)
+	_, err = client.Get(context.Background(), product.Id)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// and a successful one closes it
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	_, err = client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+	client.cache = newCache(time.Minute)
+	_, err = client.Get(context.Background(), product.Id)
+	require.NoError(t, err)
+}
+
+func Test_Client_Ping(t *testing.T) {
+	service := &productService{}
+	client := newTestClient(t, service, Options{BreakerThreshold: 1})
+	require.NoError(t, client.Ping(context.Background()))
+
+	// the breaker does not stop a ping from reaching product-service
+	service.status = func(int64) int { return http.StatusServiceUnavailable }
+	_, err := client.Get(context.Background(), uuid.New())
+	require.Error(t, err)
+
+	calls := service.calls.Load()
+	var status *StatusError
+	require.ErrorAs(t, client.Ping(context.Background()), &status)
+	require.Equal(t, http.StatusServiceUnavailable, status.StatusCode)
+	require.Equal(t, calls+1, service.calls.Load())
+}
+
+func Test_Client_GetMany_Batch(t *testing.T) {
+	catalog := map[uuid.UUID]Product{}
+	ids := []uuid.UUID{}
+	for range 5 {
+		product := Product{Id: uuid.New(), Price: money.MustParse("2.5", "GBP")}
+		catalog[product.Id] = product
+		ids = append(ids, product.Id)
+	}
+
+	service := &productService{catalog: catalog, batch: true}
+	client := newTestClient(t, service, Options{BatchSize: 2})
+
+	got, err := client.GetMany(context.Background(), append(ids, ids[0]))
+	require.NoError(t, err)
+	require.Equal(t, catalog, got)
+	require.EqualValues(t, 3, service.batchCalls.Load())
+	require.EqualValues(t, 2, service.maxBatchSize.Load())
+	require.EqualValues(t, 0, service.calls.Load()-service.batchCalls.Load(), "no single lookups")
+
+	// everything is cached now
+	_, err = client.GetMany(context.Background(), ids)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, service.batchCalls.Load())
+}
+
+func Test_Client_GetMany_FallsBackToSingleLookups(t *testing.T) {
+	catalog := map[uuid.UUID]Product{}
+	ids := []uuid.UUID{}
+	for range 10 {
+		product := Product{Id: uuid.New(), Price: money.MustParse("2.5", "GBP")}
+		catalog[product.Id] = product
+		ids = append(ids, product.Id)
+	}
+
+	// every request takes 50ms, so only concurrent lookups finish quickly
+	service := &productService{catalog: catalog, delay: 50 * time.Millisecond}
+	client := newTestClient(t, service, Options{MaxConcurrency: 10})
+
+	start := time.Now()
+	got, err := client.GetMany(context.Background(), ids)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 400*time.Millisecond)
+	require.Equal(t, catalog, got)
+	require.True(t, client.noBatch.Load())
+
+	// the batch endpoint is not asked for again
+	client.cache = newCache(time.Minute)
+	calls := service.calls.Load()
+	_, err = client.GetMany(context.Background(), ids)
+	require.NoError(t, err)
+	require.EqualValues(t, calls+10, service.calls.Load())
+}
+
+func Test_Client_GetMany_Missing(t *testing.T) {
+	for _, batch := range []bool{true, false} {
+		product := Product{Id: uuid.New(), Price: money.MustParse("1", "GBP")}
+		missing := []uuid.UUID{uuid.New(), uuid.New()}
+		service := &productService{catalog: map[uuid.UUID]Product{product.Id: product}, batch: batch}
+		client := newTestClient(t, service, Options{})
+
+		_, err := client.GetMany(context.Background(), []uuid.UUID{missing[0], product.Id, missing[1]})
+		require.ErrorIs(t, err, ErrNotFound)
+
+		var missingErr *MissingError
+		require.ErrorAs(t, err, &missingErr)
+		require.Equal(t, missing, missingErr.Ids)
+	}
+}
+
+func Test_Client_Refresh(t *testing.T) {
+	for _, batch := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch=%t", batch), func(t *testing.T) {
+			product := Product{Id: uuid.New(), Price: money.MustParse("2.50", "GBP")}
+			service := &productService{catalog: map[uuid.UUID]Product{product.Id: product}, batch: batch}
+			client := newTestClient(t, service, Options{})
+			ctx := context.Background()
+
+			_, err := client.GetMany(ctx, []uuid.UUID{product.Id})
+			require.NoError(t, err)
+
+			service.catalog[product.Id] = Product{Id: product.Id, Price: money.MustParse("2.75", "GBP")}
+
+			// GetMany still has the old price cached, Refresh goes and gets the new one
+			got, err := client.GetMany(ctx, []uuid.UUID{product.Id})
+			require.NoError(t, err)
+			require.Equal(t, money.MustParse("2.50", "GBP"), got[product.Id].Price)
+
+			got, err = client.Refresh(ctx, []uuid.UUID{product.Id})
+			require.NoError(t, err)
+			require.Equal(t, money.MustParse("2.75", "GBP"), got[product.Id].Price)
+
+			got, err = client.GetMany(ctx, []uuid.UUID{product.Id})
+			require.NoError(t, err)
+			require.Equal(t, money.MustParse("2.75", "GBP"), got[product.Id].Price)
+
+			// a product that has gone is forgotten
+			delete(service.catalog, product.Id)
+			_, err = client.Refresh(ctx, []uuid.UUID{product.Id})
+			require.ErrorIs(t, err, ErrNotFound)
+			_, err = client.GetMany(ctx, []uuid.UUID{product.Id})
+			require.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
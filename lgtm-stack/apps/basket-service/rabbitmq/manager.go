@@ -0,0 +1,346 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrNotConnected is returned when publishing while the manager is
+	// between connections.
+	ErrNotConnected = errors.New("rabbitmq: not connected")
+
+	// ErrClosed is returned when publishing after Close.
+	ErrClosed = errors.New("rabbitmq: manager closed")
+
+	// ErrNacked is returned when the broker negatively acknowledges a
+	// publishing.
+	ErrNacked = errors.New("rabbitmq: publishing was nacked by the broker")
+)
+
+// Topology declares the exchanges, queues and bindings the service relies
+// on. It runs on a dedicated channel every time a connection is established,
+// so it must be idempotent.
+type Topology func(ch *amqp091.Channel) error
+
+type Options struct {
+	// PoolSize is the number of idle confirm-mode channels kept open for
+	// publishing. Defaults to 4.
+	PoolSize int
+
+	// MinBackoff and MaxBackoff bound the exponential delay between
+	// reconnect attempts. Default to 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Manager owns a RabbitMQ connection for the lifetime of the service. It
+// reconnects with backoff when the broker closes the connection and hands out
+// publisher-confirm channels from a pool.
+type Manager struct {
+	url      string
+	topology Topology
+	opts     Options
+
+	mu     sync.RWMutex
+	conn   *amqp091.Connection
+	closed bool
+
+	pool chan *amqp091.Channel
+	done chan struct{}
+
+	// publishing counts publishings still waiting for their confirm.
+	publishing sync.WaitGroup
+}
+
+// Dial connects to url, declares the topology and starts watching the
+// connection. The first connection is not retried; an error here usually
+// means the service is misconfigured.
+func Dial(url string, topology Topology, opts Options) (*Manager, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 4
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+
+	m := &Manager{
+		url:      url,
+		topology: topology,
+		opts:     opts,
+		pool:     make(chan *amqp091.Channel, opts.PoolSize),
+		done:     make(chan struct{}),
+	}
+
+	conn, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	m.conn = conn
+	go m.watch(conn)
+
+	return m, nil
+}
+
+// Publish publishes msg on a confirm-mode channel and blocks until the broker
+// acknowledges it or ctx is done.
+func (m *Manager) Publish(ctx context.Context, exchange, key string, msg amqp091.Publishing) error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrClosed
+	}
+	m.publishing.Add(1)
+	m.mu.RUnlock()
+	defer m.publishing.Done()
+
+	ch, err := m.acquire()
+	if err != nil {
+		return err
+	}
+	defer m.release(ch)
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		exchange, // exchange
+		key,      // routing key
+		false,    // mandatory
+		false,    // immediate
+		msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !acked {
+		return ErrNacked
+	}
+
+	return nil
+}
+
+// IsOpen reports whether the manager currently holds an open connection.
+func (m *Manager) IsOpen() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return !m.closed && m.conn != nil && !m.conn.IsClosed()
+}
+
+// Ping checks that the manager is connected and can open a channel, by taking
+// one from the pool and handing it back.
+func (m *Manager) Ping() error {
+	ch, err := m.acquire()
+	if err != nil {
+		return err
+	}
+
+	m.release(ch)
+	return nil
+}
+
+// Close stops reconnecting and closes every pooled channel and the
+// connection, once publishings in progress have been confirmed.
+func (m *Manager) Close() error {
+	return m.Shutdown(context.Background())
+}
+
+// Shutdown is Close with a deadline: new publishings fail with ErrClosed
+// straight away, and those in progress have until ctx is done to be confirmed
+// before the connection is closed under them.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	conn := m.conn
+	m.mu.Unlock()
+
+	confirmed := make(chan struct{})
+	go func() {
+		m.publishing.Wait()
+		close(confirmed)
+	}()
+
+	var err error
+	select {
+	case <-confirmed:
+	case <-ctx.Done():
+		err = fmt.Errorf("waiting for publisher confirms: %w", ctx.Err())
+	}
+
+	close(m.done)
+	m.drain()
+
+	if conn == nil || conn.IsClosed() {
+		return err
+	}
+
+	return errors.Join(err, conn.Close())
+}
+
+// connect dials the broker and declares the topology. Publishing channels are
+// opened lazily by acquire.
+func (m *Manager) connect() (*amqp091.Connection, error) {
+	conn, err := amqp091.Dial(m.url)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer ch.Close()
+
+	if err := m.topology(ch); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// watch waits for conn to close and reconnects until it succeeds or the
+// manager is closed.
+func (m *Manager) watch(conn *amqp091.Connection) {
+	select {
+	case <-m.done:
+		return
+	case amqpErr, ok := <-conn.NotifyClose(make(chan *amqp091.Error, 1)):
+		if ok {
+			slog.Warn("RabbitMQ connection closed", slog.Any("error", amqpErr))
+		}
+	}
+
+	// channels from the old connection are already closed
+	m.drain()
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-m.done:
+			return
+		case <-time.After(m.backoff(attempt)):
+		}
+
+		conn, err := m.connect()
+		if err != nil {
+			slog.Warn("Failed to reconnect to RabbitMQ", slog.Int("attempt", attempt+1), slog.Any("error", err))
+			continue
+		}
+
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			conn.Close()
+			return
+		}
+		m.conn = conn
+		m.mu.Unlock()
+
+		slog.Info("Reconnected to RabbitMQ", slog.Int("attempt", attempt+1))
+		go m.watch(conn)
+		return
+	}
+}
+
+// backoff returns the delay before reconnect attempt n: exponential from
+// MinBackoff, capped at MaxBackoff, with up to 50% jitter.
+func (m *Manager) backoff(attempt int) time.Duration {
+	delay := m.opts.MaxBackoff
+	if attempt < 32 {
+		delay = min(m.opts.MinBackoff<<attempt, m.opts.MaxBackoff)
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// acquire returns an idle pooled channel or opens a new one.
+func (m *Manager) acquire() (*amqp091.Channel, error) {
+	for {
+		select {
+		case ch := <-m.pool:
+			if !ch.IsClosed() {
+				return ch, nil
+			}
+		default:
+			return m.open()
+		}
+	}
+}
+
+// release returns ch to the pool, closing it if the pool is already full.
+func (m *Manager) release(ch *amqp091.Channel) {
+	if ch.IsClosed() {
+		return
+	}
+
+	m.mu.RLock()
+	closed := m.closed
+	m.mu.RUnlock()
+
+	if closed {
+		ch.Close()
+		return
+	}
+
+	select {
+	case m.pool <- ch:
+	default:
+		ch.Close()
+	}
+}
+
+func (m *Manager) open() (*amqp091.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	if m.conn == nil || m.conn.IsClosed() {
+		return nil, ErrNotConnected
+	}
+
+	ch, err := m.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	return ch, nil
+}
+
+// drain closes every idle channel in the pool.
+func (m *Manager) drain() {
+	for {
+		select {
+		case ch := <-m.pool:
+			ch.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+
+	"github.com/google/uuid"
+)
+
+type memoryEntry struct {
+	basket    model.Basket
+	expiresAt time.Time
+}
+
+// MemoryStore is a BasketStore held in process memory. It is intended for
+// tests and local runs; nothing survives a restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	baskets map[uuid.UUID]memoryEntry
+	pending []outbox.Event
+
+	// now is swapped out in tests to control expiry.
+	now func() time.Time
+}
+
+var _ BasketStore = (*MemoryStore)(nil)
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		baskets: make(map[uuid.UUID]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id uuid.UUID) (*model.Basket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.live(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	basket := clone(entry.basket)
+	return &basket, nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, basket *model.Basket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.live(basket.Id); ok {
+		return ErrAlreadyExists
+	}
+
+	basket.Version = 1
+	basket.Touch(s.now())
+	s.put(basket)
+	return nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, basket *model.Basket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(basket); err != nil {
+		return err
+	}
+
+	basket.Version++
+	basket.Touch(s.now())
+	s.put(basket)
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id uuid.UUID, version uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.live(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	if entry.basket.Version != version {
+		return ErrConflict
+	}
+
+	delete(s.baskets, id)
+	return nil
+}
+
+func (s *MemoryStore) ListByOwner(ctx context.Context, ownerId string) ([]model.Basket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baskets := []model.Basket{}
+	for id, entry := range s.baskets {
+		if entry.basket.OwnerId != ownerId {
+			continue
+		}
+
+		if _, ok := s.live(id); ok {
+			baskets = append(baskets, clone(entry.basket))
+		}
+	}
+
+	return baskets, nil
+}
+
+func (s *MemoryStore) Idle(ctx context.Context, status model.Status, before time.Time, limit int) ([]model.Basket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baskets := []model.Basket{}
+	for id, entry := range s.baskets {
+		if entry.basket.Status != status || !entry.basket.LastWritten().Before(before) {
+			continue
+		}
+
+		if _, ok := s.live(id); ok {
+			baskets = append(baskets, clone(entry.basket))
+		}
+	}
+
+	slices.SortFunc(baskets, func(a, b model.Basket) int {
+		return a.LastWritten().Compare(b.LastWritten())
+	})
+
+	return baskets[:min(limit, len(baskets))], nil
+}
+
+func (s *MemoryStore) UpdateWithEvent(ctx context.Context, basket *model.Basket, event outbox.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(basket); err != nil {
+		return err
+	}
+
+	basket.Version++
+	basket.Touch(s.now())
+	s.put(basket)
+	s.pending = append(s.pending, event)
+	return nil
+}
+
+func (s *MemoryStore) Pending(ctx context.Context, limit int) ([]outbox.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]outbox.Event(nil), s.pending[:min(limit, len(s.pending))]...), nil
+}
+
+func (s *MemoryStore) MarkSent(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, event := range s.pending {
+		if event.Id == id {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Oldest(ctx context.Context) (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.pending) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return s.pending[0].CreatedAt, true, nil
+}
+
+// live returns the entry for id if it exists and has not expired. Callers
+// must hold the lock.
+func (s *MemoryStore) live(id uuid.UUID) (memoryEntry, bool) {
+	entry, ok := s.baskets[id]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return memoryEntry{}, false
+	}
+
+	return entry, true
+}
+
+// check returns ErrNotFound or ErrConflict if basket cannot be written over
+// what is stored. Callers must hold the lock.
+func (s *MemoryStore) check(basket *model.Basket) error {
+	entry, ok := s.live(basket.Id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	if entry.basket.Version != basket.Version {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// put stores a copy of basket and restarts its time to live. Callers must
+// hold the write lock.
+func (s *MemoryStore) put(basket *model.Basket) {
+	s.baskets[basket.Id] = memoryEntry{
+		basket:    clone(*basket),
+		expiresAt: s.now().Add(s.ttl),
+	}
+}
+
+// clone copies the basket so callers cannot mutate what is stored.
+func clone(basket model.Basket) model.Basket {
+	basket.Items = append([]model.BasketItem(nil), basket.Items...)
+	basket.Coupons = append([]string(nil), basket.Coupons...)
+	basket.History = append([]model.Transition(nil), basket.History...)
+	return basket
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"basket-service/model"
+	"money-go"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MemoryStore_CreateAndGet(t *testing.T) {
+	s := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{
+		Id:    uuid.New(),
+		Items: []model.BasketItem{{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("9.99", model.Currency), Quantity: 2}},
+	}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.ErrorIs(t, s.Create(ctx, &basket), ErrAlreadyExists)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, basket, *got)
+
+	// the stored basket must not alias the caller's copy
+	got.Items[0].Quantity = 10
+	again, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), again.Items[0].Quantity)
+}
+
+func Test_MemoryStore_TTL(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore(time.Hour)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	basket := model.Basket{Id: uuid.New()}
+	require.NoError(t, s.Create(ctx, &basket))
+
+	// reads do not extend the time to live, writes do
+	now = now.Add(59 * time.Minute)
+	_, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.NoError(t, s.Update(ctx, &basket))
+
+	now = now.Add(59 * time.Minute)
+	_, err = s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = s.Get(ctx, basket.Id)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Update(ctx, &basket), ErrNotFound)
+	require.ErrorIs(t, s.Delete(ctx, basket.Id, basket.Version), ErrNotFound)
+
+	// an expired id can be reused
+	require.NoError(t, s.Create(ctx, &basket))
+}
+
+func Test_MemoryStore_ListByOwner(t *testing.T) {
+	s := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	mine := model.Basket{Id: uuid.New(), OwnerId: "alice"}
+	theirs := model.Basket{Id: uuid.New(), OwnerId: "bob"}
+	anonymous := model.Basket{Id: uuid.New()}
+	for _, b := range []*model.Basket{&mine, &theirs, &anonymous} {
+		require.NoError(t, s.Create(ctx, b))
+	}
+
+	baskets, err := s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, []model.Basket{mine}, baskets)
+
+	require.NoError(t, s.Delete(ctx, mine.Id, mine.Version))
+	baskets, err = s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, baskets)
+}
+
+func Test_MemoryStore_Update_Conflict(t *testing.T) {
+	s := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{Id: uuid.New()}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.Equal(t, uint64(1), basket.Version)
+
+	stale := basket
+	require.NoError(t, s.Update(ctx, &basket))
+	require.Equal(t, uint64(2), basket.Version)
+	require.ErrorIs(t, s.Update(ctx, &stale), ErrConflict)
+	require.ErrorIs(t, s.Delete(ctx, stale.Id, stale.Version), ErrConflict)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Version)
+}
+
+func Test_MemoryStore_Idle(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore(24 * time.Hour)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	older := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	require.NoError(t, s.Create(ctx, &older))
+	now = now.Add(time.Minute)
+
+	old := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	require.NoError(t, s.Create(ctx, &old))
+	checkedOut := model.Basket{Id: uuid.New(), Status: model.StatusCheckedOut}
+	require.NoError(t, s.Create(ctx, &checkedOut))
+	checkingOut := model.Basket{Id: uuid.New(), Status: model.StatusCheckingOut}
+	require.NoError(t, s.Create(ctx, &checkingOut))
+
+	now = now.Add(time.Hour)
+	recent := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	require.NoError(t, s.Create(ctx, &recent))
+
+	idle, err := s.Idle(ctx, model.StatusOpen, now.Add(-30*time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.Basket{older, old}, idle)
+
+	idle, err = s.Idle(ctx, model.StatusOpen, now.Add(-30*time.Minute), 1)
+	require.NoError(t, err)
+	require.Equal(t, []model.Basket{older}, idle)
+
+	idle, err = s.Idle(ctx, model.StatusCheckingOut, now.Add(-30*time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.Basket{checkingOut}, idle)
+
+	// writing a basket makes it active again
+	require.NoError(t, s.Update(ctx, &older))
+	idle, err = s.Idle(ctx, model.StatusOpen, now.Add(-30*time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.Basket{old}, idle)
+}
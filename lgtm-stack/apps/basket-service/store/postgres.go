@@ -0,0 +1,324 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS baskets (
+	id         uuid PRIMARY KEY,
+	owner_id   text,
+	data       jsonb NOT NULL,
+	expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS baskets_owner_id_idx ON baskets (owner_id);
+
+CREATE TABLE IF NOT EXISTS outbox (
+	id          uuid PRIMARY KEY,
+	type        text NOT NULL,
+	exchange    text NOT NULL,
+	routing_key text NOT NULL,
+	headers     jsonb NOT NULL,
+	body        bytea NOT NULL,
+	created_at  timestamptz NOT NULL,
+	sent_at     timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (created_at) WHERE sent_at IS NULL;
+`
+
+// PostgresStore is a BasketStore backed by a "baskets" table. Expired rows are
+// ignored by every query and overwritten when a basket with the same id is
+// created again.
+//
+// Outbox events live in an "outbox" table; sent events are kept with their
+// sent_at time rather than deleted.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+	ttl  time.Duration
+}
+
+var _ BasketStore = (*PostgresStore)(nil)
+
+// NewPostgresStore creates the baskets and outbox tables if they do not
+// already exist.
+func NewPostgresStore(ctx context.Context, pool *pgxpool.Pool, ttl time.Duration) (*PostgresStore, error) {
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{pool: pool, ttl: ttl}, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*model.Basket, error) {
+	var data []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT data FROM baskets WHERE id = $1 AND expires_at > now()`,
+		id,
+	).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var basket model.Basket
+	if err := basket.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+func (s *PostgresStore) Create(ctx context.Context, basket *model.Basket) error {
+	basket.Version = 1
+	basket.Touch(time.Now())
+	data, err := basket.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO baskets (id, owner_id, data, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (id) DO UPDATE
+			SET owner_id = EXCLUDED.owner_id, data = EXCLUDED.data, expires_at = EXCLUDED.expires_at
+			WHERE baskets.expires_at <= now()`,
+		basket.Id, ownerOrNull(basket.OwnerId), data, s.ttl.Seconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Update(ctx context.Context, basket *model.Basket) error {
+	return s.update(ctx, s.pool, basket)
+}
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (s *PostgresStore) update(ctx context.Context, db querier, basket *model.Basket) error {
+	next := *basket
+	next.Version++
+	next.Touch(time.Now())
+
+	data, err := next.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// baskets written before versioning have no version in their data
+	tag, err := db.Exec(ctx,
+		`UPDATE baskets SET owner_id = $2, data = $3, expires_at = now() + make_interval(secs => $4)
+		WHERE id = $1 AND expires_at > now() AND coalesce((data->>'version')::bigint, 0) = $5`,
+		basket.Id, ownerOrNull(basket.OwnerId), data, s.ttl.Seconds(), basket.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return missed(ctx, db, basket.Id)
+	}
+
+	basket.Version = next.Version
+	basket.UpdatedAt = next.UpdatedAt
+	return nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id uuid.UUID, version uint64) error {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM baskets
+		WHERE id = $1 AND expires_at > now() AND coalesce((data->>'version')::bigint, 0) = $2`,
+		id, version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return missed(ctx, s.pool, id)
+	}
+
+	return nil
+}
+
+// missed returns why a write guarded by a basket's version touched no rows:
+// ErrConflict if the basket is still there, so its version must have moved
+// on, and ErrNotFound if it is not.
+func missed(ctx context.Context, db querier, id uuid.UUID) error {
+	var exists bool
+	err := db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM baskets WHERE id = $1 AND expires_at > now())`,
+		id,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrConflict
+	}
+
+	return ErrNotFound
+}
+
+func (s *PostgresStore) ListByOwner(ctx context.Context, ownerId string) ([]model.Basket, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT data FROM baskets WHERE owner_id = $1 AND expires_at > now()`,
+		ownerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baskets := []model.Basket{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var basket model.Basket
+		if err := basket.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, basket)
+	}
+
+	return baskets, rows.Err()
+}
+
+// Idle goes by expires_at, which every write sets to the time of the write
+// plus the TTL.
+func (s *PostgresStore) Idle(ctx context.Context, status model.Status, before time.Time, limit int) ([]model.Basket, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT data FROM baskets
+		WHERE expires_at > now() AND expires_at < $1::timestamptz + make_interval(secs => $2) AND data->>'status' = $3
+		ORDER BY expires_at LIMIT $4`,
+		before, s.ttl.Seconds(), status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baskets := []model.Basket{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var basket model.Basket
+		if err := basket.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, basket)
+	}
+
+	return baskets, rows.Err()
+}
+
+func (s *PostgresStore) UpdateWithEvent(ctx context.Context, basket *model.Basket, event outbox.Event) error {
+	headers, err := json.Marshal(event.Headers)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := s.update(ctx, tx, basket); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx,
+			`INSERT INTO outbox (id, type, exchange, routing_key, headers, body, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			event.Id, event.Type, event.Exchange, event.RoutingKey, headers, event.Body, event.CreatedAt,
+		)
+
+		return err
+	})
+}
+
+func (s *PostgresStore) Pending(ctx context.Context, limit int) ([]outbox.Event, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, type, exchange, routing_key, headers, body, created_at FROM outbox
+		WHERE sent_at IS NULL ORDER BY created_at LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+	for rows.Next() {
+		var event outbox.Event
+		var headers []byte
+		if err := rows.Scan(&event.Id, &event.Type, &event.Exchange, &event.RoutingKey, &headers, &event.Body, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(headers, &event.Headers); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *PostgresStore) MarkSent(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `UPDATE outbox SET sent_at = now() WHERE id = $1`, id)
+	return err
+}
+
+func (s *PostgresStore) Oldest(ctx context.Context) (time.Time, bool, error) {
+	var oldest *time.Time
+	err := s.pool.QueryRow(ctx, `SELECT min(created_at) FROM outbox WHERE sent_at IS NULL`).Scan(&oldest)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+
+	return *oldest, true, nil
+}
+
+func ownerOrNull(ownerId string) *string {
+	if ownerId == "" {
+		return nil
+	}
+
+	return &ownerId
+}
@@ -0,0 +1,381 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a BasketStore backed by Redis. Each basket is stored in its
+// versioned binary form (see model.Basket.MarshalBinary) under "basket:{id}"
+// with the store's TTL, and baskets with an owner are indexed in a set under
+// "basket:owner:{ownerId}". For Idle, baskets whose status can still change
+// are also indexed in a sorted set per status, scored by when they were last
+// written: "basket:touched" for open baskets, and "basket:touched:{status}"
+// for the others.
+//
+// Outbox events are JSON values in the "outbox:events" hash, keyed by event id,
+// and their ids are queued in the "outbox:pending" sorted set scored by
+// creation time.
+type RedisStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+const (
+	outboxEventsKey  = "outbox:events"
+	outboxPendingKey = "outbox:pending"
+)
+
+// touchedStatuses are the statuses indexed for Idle.
+var touchedStatuses = []model.Status{model.StatusOpen, model.StatusCheckingOut, model.StatusAbandoned}
+
+var _ BasketStore = (*RedisStore)(nil)
+
+func NewRedisStore(rdb *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{rdb: rdb, ttl: ttl}
+}
+
+func basketKey(id uuid.UUID) string {
+	return fmt.Sprintf("basket:%s", id)
+}
+
+func ownerKey(ownerId string) string {
+	return fmt.Sprintf("basket:owner:%s", ownerId)
+}
+
+// touchedKey is the Idle index for baskets in status. Open baskets keep the
+// key they were indexed under before other statuses were.
+func touchedKey(status model.Status) string {
+	if status == model.StatusOpen {
+		return "basket:touched"
+	}
+
+	return fmt.Sprintf("basket:touched:%s", status)
+}
+
+func (s *RedisStore) Get(ctx context.Context, id uuid.UUID) (*model.Basket, error) {
+	var basket model.Basket
+	err := s.rdb.Get(ctx, basketKey(id)).Scan(&basket)
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
+
+func (s *RedisStore) Create(ctx context.Context, basket *model.Basket) error {
+	basket.Version = 1
+	basket.Touch(time.Now())
+	err := s.rdb.SetArgs(ctx, basketKey(basket.Id), basket, redis.SetArgs{Mode: "NX", TTL: s.ttl}).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.index(ctx, basket)
+}
+
+func (s *RedisStore) Update(ctx context.Context, basket *model.Basket) error {
+	return s.update(ctx, basket, nil)
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id uuid.UUID, version uint64) error {
+	key := basketKey(id)
+
+	// as in update, WATCH aborts the delete if the basket is written after
+	// its version is checked
+	err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		var stored model.Basket
+		err := tx.Get(ctx, key).Scan(&stored)
+		if errors.Is(err, redis.Nil) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if stored.Version != version {
+			return ErrConflict
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			for _, status := range touchedStatuses {
+				pipe.ZRem(ctx, touchedKey(status), id.String())
+			}
+			if stored.OwnerId != "" {
+				pipe.SRem(ctx, ownerKey(stored.OwnerId), id.String())
+			}
+			return nil
+		})
+
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrConflict
+	}
+
+	return err
+}
+
+func (s *RedisStore) ListByOwner(ctx context.Context, ownerId string) ([]model.Basket, error) {
+	ids, err := s.rdb.SMembers(ctx, ownerKey(ownerId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	baskets := []model.Basket{}
+	if len(ids) == 0 {
+		return baskets, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("basket:%s", id)
+	}
+
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []any
+	for i, value := range values {
+		// the basket expired but its index entry is still around
+		if value == nil {
+			expired = append(expired, ids[i])
+			continue
+		}
+
+		var basket model.Basket
+		if err := basket.UnmarshalBinary([]byte(value.(string))); err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, basket)
+	}
+
+	if len(expired) > 0 {
+		if err := s.rdb.SRem(ctx, ownerKey(ownerId), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return baskets, nil
+}
+
+func (s *RedisStore) Idle(ctx context.Context, status model.Status, before time.Time, limit int) ([]model.Basket, error) {
+	ids, err := s.rdb.ZRangeArgs(ctx, redis.ZRangeArgs{
+		Key:     touchedKey(status),
+		Start:   "-inf",
+		Stop:    fmt.Sprintf("(%d", before.UnixMilli()),
+		ByScore: true,
+		Count:   int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	baskets := []model.Basket{}
+	if len(ids) == 0 {
+		return baskets, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("basket:%s", id)
+	}
+
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []any
+	for i, value := range values {
+		// the basket expired but its index entry is still around
+		if value == nil {
+			stale = append(stale, ids[i])
+			continue
+		}
+
+		var basket model.Basket
+		if err := basket.UnmarshalBinary([]byte(value.(string))); err != nil {
+			return nil, err
+		}
+
+		// it has been written, or changed status, since it was indexed
+		if basket.Status != status || !basket.LastWritten().Before(before) {
+			continue
+		}
+
+		baskets = append(baskets, basket)
+	}
+
+	if len(stale) > 0 {
+		if err := s.rdb.ZRem(ctx, touchedKey(status), stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return baskets, nil
+}
+
+func (s *RedisStore) UpdateWithEvent(ctx context.Context, basket *model.Basket, event outbox.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, basket, func(pipe redis.Pipeliner) {
+		pipe.HSet(ctx, outboxEventsKey, event.Id.String(), data)
+		pipe.ZAdd(ctx, outboxPendingKey, redis.Z{
+			Score:  float64(event.CreatedAt.UnixMilli()),
+			Member: event.Id.String(),
+		})
+	})
+}
+
+func (s *RedisStore) Pending(ctx context.Context, limit int) ([]outbox.Event, error) {
+	ids, err := s.rdb.ZRange(ctx, outboxPendingKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.rdb.HMGet(ctx, outboxEventsKey, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]outbox.Event, 0, len(values))
+	for _, value := range values {
+		// only possible if the event was marked sent between the two reads
+		if value == nil {
+			continue
+		}
+
+		var event outbox.Event
+		if err := json.Unmarshal([]byte(value.(string)), &event); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (s *RedisStore) MarkSent(ctx context.Context, id uuid.UUID) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, outboxPendingKey, id.String())
+		pipe.HDel(ctx, outboxEventsKey, id.String())
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) Oldest(ctx context.Context) (time.Time, bool, error) {
+	oldest, err := s.rdb.ZRangeWithScores(ctx, outboxPendingKey, 0, 0).Result()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if len(oldest) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return time.UnixMilli(int64(oldest[0].Score)), true, nil
+}
+
+// update writes the next version of basket, along with whatever also queues in
+// the same transaction.
+func (s *RedisStore) update(ctx context.Context, basket *model.Basket, also func(pipe redis.Pipeliner)) error {
+	key := basketKey(basket.Id)
+	next := *basket
+	next.Version++
+	next.Touch(time.Now())
+
+	// WATCH makes the version check and the writes one atomic unit; the
+	// transaction is aborted if the basket changes or expires in between
+	err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		var stored model.Basket
+		err := tx.Get(ctx, key).Scan(&stored)
+		if errors.Is(err, redis.Nil) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if stored.Version != basket.Version {
+			return ErrConflict
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, next, s.ttl)
+			if also != nil {
+				also(pipe)
+			}
+			return nil
+		})
+
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	basket.Version = next.Version
+	basket.UpdatedAt = next.UpdatedAt
+	return s.index(ctx, basket)
+}
+
+// index records the basket against its owner, and in the Idle index for its
+// status, when it was last written. The owner index lives as long as the
+// newest basket in it; stale members of either are pruned by ListByOwner and
+// Idle.
+func (s *RedisStore) index(ctx context.Context, basket *model.Basket) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, status := range touchedStatuses {
+			if status != basket.Status {
+				pipe.ZRem(ctx, touchedKey(status), basket.Id.String())
+				continue
+			}
+
+			pipe.ZAdd(ctx, touchedKey(status), redis.Z{
+				Score:  float64(basket.LastWritten().UnixMilli()),
+				Member: basket.Id.String(),
+			})
+		}
+
+		if basket.OwnerId != "" {
+			pipe.SAdd(ctx, ownerKey(basket.OwnerId), basket.Id.String())
+			pipe.Expire(ctx, ownerKey(basket.OwnerId), s.ttl)
+		}
+		return nil
+	})
+
+	return err
+}
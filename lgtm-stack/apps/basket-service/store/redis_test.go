@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+	"money-go"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{
+		Id:      uuid.New(),
+		OwnerId: "alice",
+		Items:   []model.BasketItem{{Id: uuid.New(), ProductId: uuid.New(), Price: money.MustParse("9.99", model.Currency), Quantity: 2}},
+	}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.ErrorIs(t, s.Create(ctx, &basket), ErrAlreadyExists)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, basket.Items, got.Items)
+	require.Equal(t, model.SchemaVersion, got.SchemaVersion)
+
+	baskets, err := s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, baskets, 1)
+	require.Equal(t, basket.Id, baskets[0].Id)
+
+	mr.FastForward(time.Hour)
+
+	_, err = s.Get(ctx, basket.Id)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Update(ctx, &basket), ErrNotFound)
+}
+
+func Test_RedisStore_UpdateWithEvent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+
+	event, err := outbox.NewEvent(ctx, "order.requested", "", "orders", basket)
+	require.NoError(t, err)
+
+	// nothing is written for a basket that does not exist
+	require.ErrorIs(t, s.UpdateWithEvent(ctx, &basket, event), ErrNotFound)
+	_, ok, err := s.Oldest(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, s.Create(ctx, &basket))
+	basket.Status = model.StatusCheckedOut
+	require.NoError(t, s.UpdateWithEvent(ctx, &basket, event))
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusCheckedOut, got.Status)
+
+	pending, err := s.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, event.Id, pending[0].Id)
+	require.Equal(t, event.Body, pending[0].Body)
+
+	oldest, ok, err := s.Oldest(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, event.CreatedAt.UnixMilli(), oldest.UnixMilli())
+
+	require.NoError(t, s.MarkSent(ctx, event.Id))
+	pending, err = s.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func Test_RedisStore_Update_Conflict(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	ctx := context.Background()
+
+	basket := model.Basket{Id: uuid.New()}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.Equal(t, uint64(1), basket.Version)
+
+	// two readers of version 1; the first write wins
+	first, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	second, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+
+	first.Status = model.StatusOpen
+	require.NoError(t, s.Update(ctx, first))
+	require.Equal(t, uint64(2), first.Version)
+	require.ErrorIs(t, s.Update(ctx, second), ErrConflict)
+
+	event, err := outbox.NewEvent(ctx, "order.requested", "", "orders", second)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.UpdateWithEvent(ctx, second, event), ErrConflict)
+
+	_, ok, err := s.Oldest(ctx)
+	require.NoError(t, err)
+	require.False(t, ok, "the event is not written when the basket is not")
+
+	require.ErrorIs(t, s.Delete(ctx, second.Id, second.Version), ErrConflict)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Version)
+
+	require.NoError(t, s.Delete(ctx, got.Id, got.Version))
+	require.ErrorIs(t, s.Delete(ctx, got.Id, got.Version), ErrNotFound)
+}
+
+func Test_RedisStore_Idle(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(rdb, time.Hour)
+	ctx := context.Background()
+
+	idle := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	gone := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	checkingOut := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	checkedOut := model.Basket{Id: uuid.New(), Status: model.StatusOpen}
+	for _, b := range []*model.Basket{&idle, &gone, &checkingOut, &checkedOut} {
+		require.NoError(t, s.Create(ctx, b))
+	}
+
+	checkingOut.Status = model.StatusCheckingOut
+	require.NoError(t, s.Update(ctx, &checkingOut))
+	checkedOut.Status = model.StatusCheckedOut
+	require.NoError(t, s.Update(ctx, &checkedOut))
+	mr.Del(basketKey(gone.Id))
+
+	got, err := s.Idle(ctx, model.StatusOpen, time.Now().Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, idle.Id, got[0].Id)
+	require.Equal(t, idle.UpdatedAt, got[0].UpdatedAt)
+
+	// the expired basket's index entry has been pruned
+	members, err := mr.ZMembers(touchedKey(model.StatusOpen))
+	require.NoError(t, err)
+	require.Equal(t, []string{idle.Id.String()}, members)
+
+	got, err = s.Idle(ctx, model.StatusOpen, idle.LastWritten(), 10)
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	// a basket moves between indexes with its status
+	got, err = s.Idle(ctx, model.StatusCheckingOut, time.Now().Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, checkingOut.Id, got[0].Id)
+}
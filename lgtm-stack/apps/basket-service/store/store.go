@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"basket-service/model"
+	"basket-service/outbox"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned when a basket does not exist or has expired.
+	ErrNotFound = errors.New("basket not found")
+
+	// ErrAlreadyExists is returned by Create when a live basket already has
+	// the same id.
+	ErrAlreadyExists = errors.New("basket already exists")
+
+	// ErrConflict is returned by Update, UpdateWithEvent and Delete when the
+	// basket has been written since the caller read it.
+	ErrConflict = errors.New("basket has been modified")
+)
+
+// BasketStore persists baskets. It also holds the outbox for events raised by
+// basket changes, so that both can be written in one transaction.
+//
+// Baskets expire after the time to live the store was created with. Create and
+// Update (re)start that clock; Get and ListByOwner do not, so a basket that is
+// only ever read still expires. Expired baskets behave exactly like baskets
+// that were never created.
+//
+// Writes are optimistic: Create stores a basket at Version 1, and Update and
+// UpdateWithEvent only succeed if the stored Version still matches the one on
+// the basket passed in, returning ErrConflict otherwise. On success the store
+// increments Version and sets UpdatedAt on both the stored basket and the
+// caller's. Delete likewise only removes a basket still at the version given.
+type BasketStore interface {
+	outbox.Store
+
+	Get(ctx context.Context, id uuid.UUID) (*model.Basket, error)
+	Create(ctx context.Context, basket *model.Basket) error
+	Update(ctx context.Context, basket *model.Basket) error
+	Delete(ctx context.Context, id uuid.UUID, version uint64) error
+	ListByOwner(ctx context.Context, ownerId string) ([]model.Basket, error)
+
+	// Idle returns up to limit baskets in status last written before the
+	// given time, least recently written first. Only baskets that can still
+	// change status are indexed for it: open, checking_out and abandoned.
+	Idle(ctx context.Context, status model.Status, before time.Time, limit int) ([]model.Basket, error)
+
+	// UpdateWithEvent updates the basket and appends event to the outbox in a
+	// single transaction: either both are written or neither is.
+	UpdateWithEvent(ctx context.Context, basket *model.Basket, event outbox.Event) error
+}
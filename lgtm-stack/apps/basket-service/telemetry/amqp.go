@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const amqpInstrumentationName = "basket-service/telemetry/amqp"
+
+// AMQPHeaderCarrier adapts AMQP message headers to a propagation.TextMapCarrier
+// so trace context and baggage can travel with a message.
+type AMQPHeaderCarrier amqp091.Table
+
+var _ propagation.TextMapCarrier = AMQPHeaderCarrier{}
+
+func (c AMQPHeaderCarrier) Get(key string) string {
+	if v, ok := c[key].(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+func (c AMQPHeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c AMQPHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// StartPublishSpan starts a producer span for publishing msg and injects the
+// span's context ("traceparent", "tracestate" and "baggage") into the message
+// headers so the consumer can continue the trace.
+//
+// The caller must end the returned span once the publish has completed, see
+// EndSpan.
+func StartPublishSpan(ctx context.Context, exchange, routingKey string, msg *amqp091.Publishing) (context.Context, trace.Span) {
+	destination := exchange
+	if destination == "" {
+		destination = routingKey
+	}
+
+	ctx, span := otel.Tracer(amqpInstrumentationName).Start(ctx, fmt.Sprintf("publish %s", destination),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingOperationName("publish"),
+			semconv.MessagingDestinationName(destination),
+			semconv.MessagingRabbitmqDestinationRoutingKey(routingKey),
+			semconv.MessagingMessageBodySize(len(msg.Body)),
+		),
+	)
+
+	if msg.MessageId != "" {
+		span.SetAttributes(semconv.MessagingMessageID(msg.MessageId))
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = amqp091.Table{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, AMQPHeaderCarrier(msg.Headers))
+
+	return ctx, span
+}
+
+// EndSpan records err on span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
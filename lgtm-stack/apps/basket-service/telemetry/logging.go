@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"service-kit/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanHandler stamps the active trace and span IDs on every record, on top of
+// the attributes service-kit/logging adds, so Loki can link a log line to its
+// trace in Tempo.
+type spanHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps h so that records logged with a context include the
+// trace_id and span_id of its span and the attributes of logging.WithAttrs.
+func NewLogHandler(h slog.Handler) slog.Handler {
+	return spanHandler{Handler: logging.NewHandler(h)}
+}
+
+// NewLogger returns a logger writing JSON records, one per line, to w, with
+// the level as "level" and the message as "msg".
+func NewLogger(w io.Writer) *slog.Logger {
+	return slog.New(NewLogHandler(slog.NewJSONHandler(w, nil)))
+}
+
+func (h spanHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String(logging.TraceIDKey, sc.TraceID().String()),
+			slog.String(logging.SpanIDKey, sc.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h spanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return spanHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h spanHandler) WithGroup(name string) slog.Handler {
+	return spanHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// LogBasket adds the basket's ID to the records logged for the request.
+func LogBasket(c *fiber.Ctx, id uuid.UUID) {
+	c.SetUserContext(logging.WithAttrs(c.UserContext(), slog.String(logging.BasketIDKey, id.String())))
+}
+
+// RequestLogger gives each request an ID, kept from the X-Request-ID header if
+// the caller sent one, adds it to the records logged for the request and logs
+// one line once it has completed. It must be registered after the otelfiber
+// middleware so the request span is available on the user context, and
+// passes a handler's error on for otelfiber to record and respond to.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		// the trace is taken from the request span rather than the
+		// traceparent header, which only names the caller's span
+		id, requestAttrs := logging.Request(c.Get(logging.RequestIDHeader), "")
+		c.Set(logging.RequestIDHeader, id)
+		c.SetUserContext(logging.WithAttrs(c.UserContext(), requestAttrs...))
+
+		err := c.Next()
+
+		attrs := []any{
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.String("path", c.Path()),
+			slog.Int("status", status(c, err)),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.InfoContext(c.UserContext(), "request completed", attrs...)
+
+		return err
+	}
+}
+
+// status is the status the response to c will have once err, if any, has been
+// handled.
+func status(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return fiber.StatusInternalServerError
+}
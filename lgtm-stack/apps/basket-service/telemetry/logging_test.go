@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"service-kit/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_RequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(NewLogger(&buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	basketId := uuid.New()
+
+	app := fiber.New()
+	app.Use(RequestLogger())
+	app.Get("/api/basket/:id", func(c *fiber.Ctx) error {
+		LogBasket(c, basketId)
+		slog.ErrorContext(c.UserContext(), "could not load basket")
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/api/basket/"+basketId.String(), nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, "req-1", res.Header.Get(logging.RequestIDHeader))
+
+	decoder := json.NewDecoder(&buf)
+	for _, msg := range []string{"could not load basket", "request completed"} {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		require.Equal(t, msg, record["msg"])
+		require.Equal(t, "req-1", record[logging.RequestIDKey])
+		require.Equal(t, basketId.String(), record[logging.BasketIDKey])
+	}
+}
+
+func Test_RequestLogger_GeneratesID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestLogger())
+	app.Get("/", func(c *fiber.Ctx) error { return nil })
+
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+
+	require.Len(t, res.Header.Get(logging.RequestIDHeader), 32)
+}
+
+func Test_RequestLogger_Error(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(NewLogger(&buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	app := fiber.New()
+	app.Use(RequestLogger())
+	app.Get("/", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+
+	// the error is still responded to, by the app's error handler
+	res, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, res.StatusCode)
+
+	var record map[string]any
+	require.NoError(t, json.NewDecoder(&buf).Decode(&record))
+	require.Equal(t, float64(fiber.StatusNotFound), record["status"])
+	require.Equal(t, fiber.ErrNotFound.Message, record["error"])
+}
+
+func Test_NewLogger_StampsSpan(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = logging.WithAttrs(ctx, slog.String(logging.BasketIDKey, "basket-1"))
+
+	logger.InfoContext(ctx, "basket created")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record[logging.TraceIDKey])
+	require.Equal(t, "00f067aa0ba902b7", record[logging.SpanIDKey])
+	require.Equal(t, "basket-1", record[logging.BasketIDKey])
+}
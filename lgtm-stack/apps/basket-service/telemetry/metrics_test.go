@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func Test_RequestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	requestMetrics, err := RequestMetrics()
+	require.NoError(t, err)
+
+	var handlerErr error
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		handlerErr = c.Next()
+		return handlerErr
+	})
+	app.Use(requestMetrics)
+	app.Get("/api/basket/:id", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	res, err := app.Test(httptest.NewRequest("GET", "/api/basket/1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	require.ErrorIs(t, handlerErr, fiber.ErrNotFound, "the error is passed on to earlier middleware")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+	histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+
+	attrs := histogram.DataPoints[0].Attributes
+	route, _ := attrs.Value("http.route")
+	require.Equal(t, attribute.StringValue("/api/basket/:id"), route)
+	status, _ := attrs.Value("http.response.status_code")
+	require.Equal(t, attribute.IntValue(fiber.StatusNotFound), status)
+}
@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ContentType is the media type of a Problem.
+const ContentType = "application/problem+json"
+
+// TypeValidation is the problem type of requests rejected by validation.
+const TypeValidation = "urn:basket-service:problem:validation"
+
+// TypePricesChanged is the problem type of a checkout turned down because
+// product prices have changed since they were added to the basket.
+const TypePricesChanged = "urn:basket-service:problem:prices-changed"
+
+// Problem is an RFC 7807 problem details object. Errors is an extension
+// member listing each invalid field. A Problem is also an error, so helpers can
+// return one for the handler to send.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+func (p Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+
+	return p.Title + ": " + p.Detail
+}
+
+// NewProblem returns a generic problem for status, titled with its reason
+// phrase.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ValidationProblem returns a 400 problem listing the field errors in err. err
+// must be, or wrap, an Errors.
+func ValidationProblem(err error) Problem {
+	var errs Errors
+	errors.As(err, &errs)
+
+	return Problem{
+		Type:   TypeValidation,
+		Title:  "One or more fields are invalid",
+		Status: http.StatusBadRequest,
+		Errors: errs,
+	}
+}
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Codes identify which check a field failed, so clients can react without
+// parsing messages.
+const (
+	CodeRequired    = "required"
+	CodeInvalidUUID = "invalid_uuid"
+	CodeMin         = "min"
+	CodeNotFound    = "not_found"
+	CodeUnsupported = "unsupported"
+	CodeUnavailable = "unavailable"
+)
+
+// FieldError is a failed check on one request field. Path is the field's JSON
+// path, e.g. "items[2].catalogId", the same shape as the path on the GraphQL
+// schema's Error type.
+type FieldError struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects every FieldError for a request rather than stopping at the
+// first one.
+type Errors []FieldError
+
+func (e *Errors) Add(path, code, message string) {
+	*e = append(*e, FieldError{Path: path, Code: code, Message: message})
+}
+
+// Err returns e as an error, or nil if nothing was added.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = fmt.Sprintf("%s: %s", err.Path, err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Index returns the path of element i of the array at path.
+func Index(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+// Field returns the path of field name of the object at path.
+func Field(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
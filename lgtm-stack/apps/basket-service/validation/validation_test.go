@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Errors(t *testing.T) {
+	var errs Errors
+	require.NoError(t, errs.Err())
+
+	errs.Add(Field(Index("items", 2), "catalogId"), CodeInvalidUUID, "Must be a valid UUID")
+	errs.Add(Field("", "quantity"), CodeMin, "Must be at least 1")
+
+	err := errs.Err()
+	require.Error(t, err)
+	require.Equal(t, "items[2].catalogId: Must be a valid UUID; quantity: Must be at least 1", err.Error())
+}
+
+func Test_ValidationProblem(t *testing.T) {
+	var errs Errors
+	errs.Add("items", CodeRequired, "At least one item is required")
+
+	data, err := json.Marshal(ValidationProblem(errs.Err()))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "urn:basket-service:problem:validation",
+		"title": "One or more fields are invalid",
+		"status": 400,
+		"errors": [{"path": "items", "code": "required", "message": "At least one item is required"}]
+	}`, string(data))
+}
+
+func Test_NewProblem(t *testing.T) {
+	problem := NewProblem(http.StatusNotFound, "Basket not found")
+	require.Equal(t, "about:blank", problem.Type)
+	require.Equal(t, "Not Found", problem.Title)
+	require.Equal(t, http.StatusNotFound, problem.Status)
+}
@@ -20,4 +20,11 @@ public record Product
 
     [Precision(18, 2)]
     public decimal Price { get; set; }
-}
\ No newline at end of file
+}
+
+public record BatchProductsRequest
+{
+    public const int MaxIds = 100;
+
+    public List<Guid> Ids { get; set; } = [];
+}
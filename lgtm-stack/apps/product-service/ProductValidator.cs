@@ -8,4 +8,13 @@ public class ProductValidator : AbstractValidator<Product>
         RuleFor(x => x.Description).Length(2, 1024);
         RuleFor(x => x.Price).GreaterThan(0).PrecisionScale(18, 2, false);
     }
-}
\ No newline at end of file
+}
+
+public class BatchProductsRequestValidator : AbstractValidator<BatchProductsRequest>
+{
+    public BatchProductsRequestValidator()
+    {
+        RuleFor(x => x.Ids).Must(ids => ids.Count <= BatchProductsRequest.MaxIds)
+            .WithMessage($"At most {BatchProductsRequest.MaxIds} ids can be requested at once.");
+    }
+}
@@ -52,6 +52,17 @@ app.MapGet("/api/products/{id}", async (ProductContext db, Guid id) =>
             ? Results.Ok(product)
             : Results.NotFound());
 
+app.MapPost("/api/products/batch", async (ProductContext db, IValidator<BatchProductsRequest> validator, BatchProductsRequest request) =>
+{
+    var result = await validator.ValidateAsync(request);
+    if (!result.IsValid)
+    {
+        return Results.ValidationProblem(result.ToDictionary());
+    }
+
+    return Results.Ok(await db.Products.Where(p => request.Ids.Contains(p.Id)).ToListAsync());
+});
+
 app.MapPost("/api/products", async (ProductContext db, IValidator<Product> validator, Product product) =>
 {
     var result = await validator.ValidateAsync(product);
@@ -0,0 +1,72 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Allocate splits m into parts proportional to ratios without losing or
+// inventing a single minor unit. Each part first gets its share rounded
+// towards zero; the units left over are then handed out one at a time, to the
+// earliest parts with a non-zero ratio first. Allocating 0.05 as 3:7 gives 0.02
+// and 0.03.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("money: no ratios to allocate by")
+	}
+
+	total := new(big.Int)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, errors.New("money: ratios must not be negative")
+		}
+		total.Add(total, big.NewInt(ratio))
+	}
+
+	if total.Sign() == 0 {
+		return nil, errors.New("money: ratios must not all be zero")
+	}
+
+	amount := big.NewInt(m.minor)
+	remainder := new(big.Int).Set(amount)
+	parts := make([]Money, len(ratios))
+
+	for i, ratio := range ratios {
+		// Quo truncates towards zero, so every share is no bigger in
+		// magnitude than its exact value
+		share := new(big.Int).Mul(amount, big.NewInt(ratio))
+		share.Quo(share, total)
+
+		parts[i] = Money{minor: share.Int64(), currency: m.currency}
+		remainder.Sub(remainder, share)
+	}
+
+	// fewer units are left over than there are non-zero ratios
+	unit := int64(remainder.Sign())
+	left := new(big.Int).Abs(remainder).Int64()
+	for i := 0; left > 0; i++ {
+		if ratios[i] == 0 {
+			continue
+		}
+
+		parts[i].minor += unit
+		left--
+	}
+
+	return parts, nil
+}
+
+// Split divides m into n parts as equal as possible, e.g. 10.00 three ways is
+// 3.34, 3.33 and 3.33.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("money: must split into at least one part")
+	}
+
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+
+	return m.Allocate(ratios...)
+}
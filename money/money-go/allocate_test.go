@@ -0,0 +1,64 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Money_Split(t *testing.T) {
+	tests := []struct {
+		amount string
+		n      int
+		want   []string
+	}{
+		{"10.00", 3, []string{"3.34", "3.33", "3.33"}},
+		{"0.05", 3, []string{"0.02", "0.02", "0.01"}},
+		{"-10.00", 3, []string{"-3.34", "-3.33", "-3.33"}},
+		{"0.01", 2, []string{"0.01", "0.00"}},
+		{"25363.71", 1, []string{"25363.71"}},
+	}
+
+	for _, test := range tests {
+		parts, err := MustParse(test.amount, "GBP").Split(test.n)
+		require.NoError(t, err)
+
+		want := make([]Money, len(test.want))
+		for i, amount := range test.want {
+			want[i] = MustParse(amount, "GBP")
+		}
+		require.Equal(t, want, parts, "%s split %d ways", test.amount, test.n)
+	}
+
+	_, err := MustParse("1", "GBP").Split(0)
+	require.Error(t, err)
+}
+
+func Test_Money_Allocate(t *testing.T) {
+	tests := []struct {
+		amount string
+		ratios []int64
+		want   []string
+	}{
+		{"0.05", []int64{3, 7}, []string{"0.02", "0.03"}},
+		{"100.00", []int64{1, 1, 1}, []string{"33.34", "33.33", "33.33"}},
+		{"100.00", []int64{50, 30, 20}, []string{"50.00", "30.00", "20.00"}},
+		{"0.03", []int64{0, 1, 1}, []string{"0.00", "0.02", "0.01"}},
+	}
+
+	for _, test := range tests {
+		parts, err := MustParse(test.amount, "GBP").Allocate(test.ratios...)
+		require.NoError(t, err)
+
+		want := make([]Money, len(test.want))
+		for i, amount := range test.want {
+			want[i] = MustParse(amount, "GBP")
+		}
+		require.Equal(t, want, parts, "%s by %v", test.amount, test.ratios)
+	}
+
+	for _, ratios := range [][]int64{nil, {0, 0}, {1, -1}} {
+		_, err := MustParse("1", "GBP").Allocate(ratios...)
+		require.Error(t, err, ratios)
+	}
+}
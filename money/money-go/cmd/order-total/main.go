@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"money-go"
+)
+
+func main() {
+	currency := flag.String("currency", "GBP", "currency to total the order in")
+	snapshot := flag.String("rates", "", "JSON or CSV exchange rate snapshot (defaults to a built-in table)")
+	flag.Parse()
+
+	provider, err := rates(*snapshot)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	order := money.Order{
+		Items: []money.OrderItem{
+			{Price: money.MustParse("25360.05", "GBP"), Quantity: 1},
+			{Price: money.MustParse("0.33", "GBP"), Quantity: 1},
+			{Price: money.MustParse("3.33", "GBP"), Quantity: 1},
+			{Price: money.MustParse("49.99", "EUR"), Quantity: 2},
+		},
+	}
+
+	total, err := order.TotalIn(context.Background(), *currency, provider)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	vat, err := total.Total.IncludedTax(money.MustParseRate("20%"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("Total %s (including %s VAT)\n", total.Total.Format("en-GB"), vat.Format("en-GB"))
+	for _, conversion := range total.Conversions {
+		fmt.Printf("  %s converted to %s at %s\n", conversion.From.Format("en-GB"), conversion.To.Format("en-GB"), conversion.Rate)
+	}
+}
+
+func rates(snapshot string) (money.ExchangeRateProvider, error) {
+	if snapshot != "" {
+		return money.OpenRates(snapshot)
+	}
+
+	asOf := time.Date(2026, time.October, 16, 16, 0, 0, 0, time.UTC)
+	return money.NewStaticRates(
+		money.ExchangeRate{From: "GBP", To: "EUR", Rate: money.MustParseRate("1.1523"), AsOf: asOf, Source: "built-in"},
+		money.ExchangeRate{From: "GBP", To: "USD", Rate: money.MustParseRate("1.3378"), AsOf: asOf, Source: "built-in"},
+	)
+}
@@ -0,0 +1,32 @@
+package money
+
+// exponents lists the currencies whose minor unit is not a hundredth of the
+// major unit. Every other currency has two decimal places.
+var exponents = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0,
+	"KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3, "VND": 0,
+}
+
+// Exponent returns how many decimal places currency has.
+func Exponent(currency string) int {
+	if exponent, ok := exponents[currency]; ok {
+		return exponent
+	}
+
+	return 2
+}
+
+// validCurrency reports whether currency looks like an ISO 4217 code.
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
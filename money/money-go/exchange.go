@@ -0,0 +1,144 @@
+package money
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrNoRate is returned when a provider has no rate between two currencies.
+var ErrNoRate = errors.New("money: no exchange rate")
+
+// ExchangeRate is a quoted rate between two currencies: one unit of From buys
+// Rate units of To. It is kept as quoted, so converting from To back to From
+// divides by Rate rather than using a rounded inverse.
+type ExchangeRate struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Rate   Rate      `json:"rate"`
+	AsOf   time.Time `json:"asOf"`
+	Source string    `json:"source,omitempty"`
+}
+
+func (r ExchangeRate) String() string {
+	return fmt.Sprintf("1 %s = %s %s as of %s", r.From, r.Rate.Decimal(), r.To, r.AsOf.Format(time.RFC3339))
+}
+
+// ExchangeRateProvider looks up the rate for converting between two
+// currencies. The rate returned may be quoted in either direction.
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from, to string) (ExchangeRate, error)
+}
+
+// Convert returns m in the other currency of rate, rounded half to even to
+// that currency's minor unit.
+func (m Money) Convert(rate ExchangeRate) (Money, error) {
+	if rate.Rate.rat().Sign() <= 0 {
+		return Money{}, fmt.Errorf("money: invalid exchange rate %s", rate)
+	}
+
+	var to string
+	factor := new(big.Rat).Set(rate.Rate.rat())
+	switch m.currency {
+	case rate.From:
+		to = rate.To
+	case rate.To:
+		to = rate.From
+		factor.Inv(factor)
+	default:
+		return Money{}, fmt.Errorf("%w: %s with a %s/%s rate", ErrCurrencyMismatch, m.currency, rate.From, rate.To)
+	}
+
+	// move between the two currencies' minor units as well
+	scale := new(big.Rat).SetFrac(pow10(Exponent(to)), pow10(Exponent(m.currency)))
+	factor.Mul(factor, scale)
+
+	converted, err := Money{minor: m.minor}.MulRate(Rate{r: factor})
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{minor: converted.minor, currency: to}, nil
+}
+
+// Conversion records an amount converted to another currency and the rate
+// that was used, so the result can be explained or reproduced later.
+type Conversion struct {
+	From Money        `json:"from"`
+	To   Money        `json:"to"`
+	Rate ExchangeRate `json:"rate"`
+}
+
+// Exchange converts m to currency at the rate provider gives. Amounts already
+// in currency are returned as they are, with a rate of 1 and no source.
+func Exchange(ctx context.Context, provider ExchangeRateProvider, m Money, currency string) (Conversion, error) {
+	if m.currency == currency {
+		return Conversion{
+			From: m,
+			To:   m,
+			Rate: ExchangeRate{From: currency, To: currency, Rate: Rate{r: big.NewRat(1, 1)}},
+		}, nil
+	}
+
+	rate, err := provider.Rate(ctx, m.currency, currency)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	converted, err := m.Convert(rate)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	if converted.currency != currency {
+		return Conversion{}, fmt.Errorf("money: provider returned a %s/%s rate for %s/%s", rate.From, rate.To, m.currency, currency)
+	}
+
+	return Conversion{From: m, To: converted, Rate: rate}, nil
+}
+
+// StaticRates is an ExchangeRateProvider with a fixed table of rates. A rate
+// quoted in one direction is also used for the other; where both directions
+// are quoted, the direct one wins.
+type StaticRates struct {
+	rates map[[2]string]ExchangeRate
+}
+
+var _ ExchangeRateProvider = (*StaticRates)(nil)
+
+// NewStaticRates returns a provider for rates. A later rate for the same pair
+// of currencies replaces an earlier one.
+func NewStaticRates(rates ...ExchangeRate) (*StaticRates, error) {
+	s := &StaticRates{rates: make(map[[2]string]ExchangeRate, len(rates))}
+	for _, rate := range rates {
+		if !validCurrency(rate.From) || !validCurrency(rate.To) || rate.From == rate.To {
+			return nil, fmt.Errorf("money: invalid currency pair %q/%q", rate.From, rate.To)
+		}
+
+		if rate.Rate.rat().Sign() <= 0 {
+			return nil, fmt.Errorf("money: invalid exchange rate %s", rate)
+		}
+
+		s.rates[[2]string{rate.From, rate.To}] = rate
+	}
+
+	return s, nil
+}
+
+func (s *StaticRates) Rate(_ context.Context, from, to string) (ExchangeRate, error) {
+	if rate, ok := s.rates[[2]string{from, to}]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := s.rates[[2]string{to, from}]; ok {
+		return rate, nil
+	}
+
+	return ExchangeRate{}, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,139 @@
+package money
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var asOf = time.Date(2026, time.October, 16, 16, 0, 0, 0, time.UTC)
+
+func gbpEur(rate string) ExchangeRate {
+	return ExchangeRate{From: "GBP", To: "EUR", Rate: MustParseRate(rate), AsOf: asOf, Source: "test"}
+}
+
+func Test_Money_Convert(t *testing.T) {
+	tests := []struct {
+		name string
+		from Money
+		rate ExchangeRate
+		want Money
+	}{
+		{"quoted direction", MustParse("100.00", "GBP"), gbpEur("1.1523"), MustParse("115.23", "EUR")},
+		{"inverse divides", MustParse("115.23", "EUR"), gbpEur("1.1523"), MustParse("100.00", "GBP")},
+		{"rounds half to even", MustParse("0.01", "GBP"), gbpEur("1.25"), MustParse("0.01", "EUR")},
+		{"rounds half to even up", MustParse("0.03", "GBP"), gbpEur("1.25"), MustParse("0.04", "EUR")},
+		{"negative", MustParse("-10.00", "GBP"), gbpEur("1.1523"), MustParse("-11.52", "EUR")},
+		{
+			"into fewer decimal places",
+			MustParse("10.00", "GBP"),
+			ExchangeRate{From: "GBP", To: "JPY", Rate: MustParseRate("201.355")},
+			MustParse("2014", "JPY"),
+		},
+		{
+			"out of fewer decimal places",
+			MustParse("2014", "JPY"),
+			ExchangeRate{From: "GBP", To: "JPY", Rate: MustParseRate("201.4")},
+			MustParse("10.00", "GBP"),
+		},
+		{
+			"into more decimal places",
+			MustParse("1.00", "GBP"),
+			ExchangeRate{From: "GBP", To: "KWD", Rate: MustParseRate("0.4087")},
+			MustParse("0.409", "KWD"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.from.Convert(test.rate)
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+
+	_, err := MustParse("1", "USD").Convert(gbpEur("1.1523"))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = MustParse("1", "GBP").Convert(gbpEur("0"))
+	require.Error(t, err)
+}
+
+func Test_Exchange(t *testing.T) {
+	ctx := context.Background()
+	rates, err := NewStaticRates(gbpEur("1.1523"))
+	require.NoError(t, err)
+
+	conversion, err := Exchange(ctx, rates, MustParse("99.98", "EUR"), "GBP")
+	require.NoError(t, err)
+	require.Equal(t, MustParse("99.98", "EUR"), conversion.From)
+	require.Equal(t, MustParse("86.77", "GBP"), conversion.To)
+	require.Equal(t, gbpEur("1.1523"), conversion.Rate)
+
+	same, err := Exchange(ctx, rates, MustParse("5.00", "GBP"), "GBP")
+	require.NoError(t, err)
+	require.Equal(t, MustParse("5.00", "GBP"), same.To)
+	require.Equal(t, "1", same.Rate.Rate.Decimal())
+
+	_, err = Exchange(ctx, rates, MustParse("1", "USD"), "GBP")
+	require.ErrorIs(t, err, ErrNoRate)
+}
+
+func Test_StaticRates(t *testing.T) {
+	ctx := context.Background()
+	eurGbp := ExchangeRate{From: "EUR", To: "GBP", Rate: MustParseRate("0.8678"), AsOf: asOf}
+
+	rates, err := NewStaticRates(gbpEur("1.1523"), eurGbp)
+	require.NoError(t, err)
+
+	// both directions are quoted, so each is used as it is
+	got, err := rates.Rate(ctx, "GBP", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, gbpEur("1.1523"), got)
+
+	got, err = rates.Rate(ctx, "EUR", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, eurGbp, got)
+
+	// a later rate for the same pair replaces an earlier one
+	rates, err = NewStaticRates(gbpEur("1.1523"), gbpEur("1.16"))
+	require.NoError(t, err)
+	got, err = rates.Rate(ctx, "EUR", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, gbpEur("1.16"), got)
+
+	for _, invalid := range []ExchangeRate{
+		{From: "GBP", To: "GBP", Rate: MustParseRate("1")},
+		{From: "gbp", To: "EUR", Rate: MustParseRate("1")},
+		gbpEur("0"),
+		gbpEur("-1.1"),
+	} {
+		_, err := NewStaticRates(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func Test_Conversion_JSON(t *testing.T) {
+	conversion := Conversion{
+		From: MustParse("100.00", "GBP"),
+		To:   MustParse("116.745", "EUR"),
+		Rate: gbpEur("1.16745"),
+	}
+
+	data, err := json.Marshal(conversion)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"from": {"amount": "100.00", "currency": "GBP"},
+		"to": {"amount": "116.74", "currency": "EUR"},
+		"rate": {"from": "GBP", "to": "EUR", "rate": "1.16745", "asOf": "2026-10-16T16:00:00Z", "source": "test"}
+	}`, string(data))
+
+	var got Conversion
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, conversion.From, got.From)
+	require.Equal(t, conversion.To, got.To)
+	require.Equal(t, conversion.Rate.Rate.Decimal(), got.Rate.Rate.Decimal())
+}
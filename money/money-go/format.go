@@ -0,0 +1,78 @@
+package money
+
+import "strings"
+
+// locale is how one language writes amounts of money.
+type locale struct {
+	group       string
+	decimal     string
+	symbolFirst bool
+	space       bool
+}
+
+// locales covers the languages this package has been asked to format for,
+// following CLDR's standard currency patterns for them.
+var locales = map[string]locale{
+	"en": {group: ",", decimal: ".", symbolFirst: true},
+	"ja": {group: ",", decimal: ".", symbolFirst: true},
+	"de": {group: ".", decimal: ",", space: true},
+	"es": {group: ".", decimal: ",", space: true},
+	"it": {group: ".", decimal: ",", space: true},
+	"fr": {group: "\u202f", decimal: ",", space: true},
+	"nl": {group: ".", decimal: ",", symbolFirst: true, space: true},
+}
+
+var symbols = map[string]string{
+	"GBP": "£",
+	"USD": "$",
+	"EUR": "€",
+	"JPY": "¥",
+}
+
+// Format writes m the way locale, a BCP 47 tag such as "en-GB" or "de-DE",
+// writes money: "£1,234.50" in en-GB and "1.234,50 €" in de-DE. Only the
+// language part of the tag is used. Locales it does not know fall back to
+// String, and currencies without a well known symbol are written with their
+// code.
+func (m Money) Format(tag string) string {
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	l, ok := locales[strings.ToLower(language)]
+	if !ok {
+		return m.String()
+	}
+
+	sign, whole, frac := m.parts()
+
+	var number strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			number.WriteString(l.group)
+		}
+		number.WriteRune(digit)
+	}
+	if frac != "" {
+		number.WriteString(l.decimal)
+		number.WriteString(frac)
+	}
+
+	symbol, ok := symbols[m.currency]
+	if !ok {
+		symbol = m.currency
+	}
+
+	// codes always need separating from the digits; a no-break space keeps
+	// the amount on one line
+	separator := ""
+	if l.space || !ok {
+		separator = "\u00a0"
+	}
+
+	if l.symbolFirst {
+		if separator != "" {
+			return symbol + separator + sign + number.String()
+		}
+		return sign + symbol + number.String()
+	}
+
+	return sign + number.String() + separator + symbol
+}
@@ -0,0 +1,35 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Money_Format(t *testing.T) {
+	tests := []struct {
+		money  Money
+		locale string
+		want   string
+	}{
+		{MustParse("1234.5", "GBP"), "en-GB", "£1,234.50"},
+		{MustParse("-1234.5", "GBP"), "en-GB", "-£1,234.50"},
+		{MustParse("25363.71", "USD"), "en-US", "$25,363.71"},
+		{MustParse("0.33", "GBP"), "en", "£0.33"},
+		{MustParse("1234567.89", "EUR"), "de-DE", "1.234.567,89\u00a0€"},
+		{MustParse("-3.33", "EUR"), "de_DE", "-3,33\u00a0€"},
+		{MustParse("1234.5", "EUR"), "fr-FR", "1\u202f234,50\u00a0€"},
+		{MustParse("1234.5", "EUR"), "nl-NL", "€\u00a01.234,50"},
+		{MustParse("1500", "JPY"), "ja-JP", "¥1,500"},
+		{MustParse("12.5", "CHF"), "en-GB", "CHF\u00a012.50"},
+		{MustParse("12.5", "CHF"), "de-CH", "12,50\u00a0CHF"},
+		{MustParse("999", "GBP"), "en-GB", "£999.00"},
+
+		// unknown locales fall back to String
+		{MustParse("12.5", "GBP"), "xx-XX", "12.50 GBP"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, test.money.Format(test.locale))
+	}
+}
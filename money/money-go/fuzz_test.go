@@ -0,0 +1,92 @@
+package money
+
+import (
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"25360.05", "0.33", "3.33", "0.125", "-1.005", "1e2", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, amount string) {
+		m, err := Parse(amount, "GBP")
+		if err != nil {
+			return
+		}
+
+		// whatever parses formats back to an amount that parses to itself
+		again, err := Parse(m.Amount(), "GBP")
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v, which does not parse back: %v", amount, m, err)
+		}
+		if again != m {
+			t.Fatalf("Parse(%q) = %v, round trips to %v", amount, m, again)
+		}
+	})
+}
+
+func FuzzSplit(f *testing.F) {
+	f.Add(int64(1000), 3)
+	f.Add(int64(2536005), 7)
+	f.Add(int64(-5), 2)
+
+	f.Fuzz(func(t *testing.T, minor int64, n int) {
+		if n <= 0 || n > 1000 {
+			return
+		}
+
+		m := New(minor, "GBP")
+		parts, err := m.Split(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// no penny is lost or invented, and parts differ by at most one
+		total, err := Sum(parts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != m {
+			t.Fatalf("%v split %d ways adds up to %v", m, n, total)
+		}
+
+		for _, part := range parts {
+			if diff := part.Minor() - parts[0].Minor(); diff > 1 || diff < -1 {
+				t.Fatalf("%v split %d ways is uneven: %v", m, n, parts)
+			}
+		}
+	})
+}
+
+func FuzzDiscount(f *testing.F) {
+	f.Add(int64(999), "10%")
+	f.Add(int64(5), "50%")
+
+	f.Fuzz(func(t *testing.T, minor int64, rate string) {
+		r, err := ParseRate(rate)
+		if err != nil {
+			return
+		}
+
+		m := New(minor, "GBP")
+		discounted, err := m.Discount(r)
+		if err != nil {
+			return
+		}
+
+		discount, err := m.MulRate(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the discount and the discounted price add back up to the price
+		total, err := discounted.Add(discount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != m {
+			t.Fatalf("%v less %s is %v, plus the %v discount is %v", m, rate, discounted, discount, total)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// MarshalJSON encodes the amount as a string so that no JSON decoder can turn
+// it into a float: {"amount":"12.30","currency":"GBP"}. An amount with no
+// currency, such as the zero Money, has no currency field.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Amount(), Currency: m.currency})
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON. A bare JSON number,
+// as amounts were stored before they had a currency, is also accepted and
+// decoded exactly to an amount with no currency and two decimal places, as is
+// an object without a currency; label it with WithCurrency. null leaves m as it
+// is.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] != '{' {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+
+		minor, err := parseMinor(number.String(), Exponent(""))
+		if err != nil {
+			return err
+		}
+
+		*m = Money{minor: minor}
+		return nil
+	}
+
+	var decoded jsonMoney
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.Currency == "" {
+		minor, err := parseMinor(decoded.Amount, Exponent(""))
+		if err != nil {
+			return err
+		}
+
+		*m = Money{minor: minor}
+		return nil
+	}
+
+	parsed, err := Parse(decoded.Amount, decoded.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,45 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Money_JSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("12.3", "GBP"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.30","currency":"GBP"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	require.Equal(t, New(1230, "GBP"), m)
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &m))
+	require.Equal(t, New(1230, "GBP"), m, "null leaves the amount alone")
+
+	// an amount stored before amounts had a currency is read exactly
+	require.NoError(t, json.Unmarshal([]byte(`0.1`), &m))
+	require.Equal(t, New(10, ""), m)
+	require.Equal(t, New(10, "GBP"), m.WithCurrency("GBP"))
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"x","currency":"GBP"}`), &m))
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"1","currency":"gbp"}`), &m))
+	require.Error(t, json.Unmarshal([]byte(`true`), &m))
+}
+
+func Test_Money_JSON_RoundTrip(t *testing.T) {
+	for _, m := range []Money{{}, New(-5, ""), New(1230, "GBP"), New(7, "JPY"), New(1234, "KWD")} {
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		var decoded Money
+		require.NoError(t, json.Unmarshal(data, &decoded), string(data))
+		require.Equal(t, m, decoded, string(data))
+	}
+
+	data, err := json.Marshal(Money{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"0.00"}`, string(data))
+}
@@ -0,0 +1,220 @@
+// Package money represents amounts of money exactly, as whole numbers of a
+// currency's minor units, and does arithmetic on them without the rounding
+// error of floats.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts of different
+	// currencies.
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+	// ErrOverflow is returned when a result does not fit in an int64 of minor
+	// units.
+	ErrOverflow = errors.New("money: amount out of range")
+)
+
+// Money is an exact amount of a currency, held as a whole number of the
+// currency's minor units (pence for GBP, yen for JPY). Two amounts are equal
+// exactly when == says so.
+//
+// The zero value has no currency and adopts the currency of whatever it is
+// added to, so it can be used to start a total.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// New returns minor units of currency, e.g. New(1234, "GBP") is £12.34.
+func New(minor int64, currency string) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Parse converts a decimal string such as "12.34" or "-0.5" to an amount of
+// currency. Digits beyond the currency's minor unit are rounded half to even
+// (banker's rounding), so "0.125" GBP is 0.12 and "0.135" GBP is 0.14.
+func Parse(amount, currency string) (Money, error) {
+	if !validCurrency(currency) {
+		return Money{}, fmt.Errorf("money: invalid currency code %q", currency)
+	}
+
+	minor, err := parseMinor(amount, Exponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// MustParse is like Parse but panics if amount is invalid. It is intended for
+// constants and tests.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() string {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// WithCurrency returns the same number of minor units labelled with currency.
+// It does not convert between currencies, Convert does; it is for amounts
+// that were recorded without one.
+func (m Money) WithCurrency(currency string) Money {
+	m.currency = currency
+	return m
+}
+
+// Cmp compares m and o, which must be in the same currency, returning -1, 0
+// or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := combine(m, o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Add returns m + o. Both must be in the same currency, unless one of them is
+// the zero value.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := combine(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+
+	sum := m.minor + o.minor
+	if (sum > m.minor) != (o.minor > 0) {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{minor: sum, currency: currency}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency, unless one of them is
+// the zero value.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+
+	return m.Add(Money{minor: -o.minor, currency: o.currency})
+}
+
+// Neg returns -m.
+func (m Money) Neg() (Money, error) {
+	if m.minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{minor: -m.minor, currency: m.currency}, nil
+}
+
+// Mul returns m multiplied by n, e.g. an item's price by its quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	product := m.minor * n
+	if n != 0 && (product/n != m.minor || (m.minor == math.MinInt64 && n == -1)) {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{minor: product, currency: m.currency}, nil
+}
+
+// Sum adds up amounts, which must all be in the same currency.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// Amount returns the amount as a decimal string with exactly as many decimal
+// places as the currency has, e.g. "12.30".
+func (m Money) Amount() string {
+	sign, whole, frac := m.parts()
+	if frac == "" {
+		return sign + whole
+	}
+
+	return sign + whole + "." + frac
+}
+
+// String returns the amount followed by its currency, e.g. "12.30 GBP".
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Amount()
+	}
+
+	return m.Amount() + " " + m.currency
+}
+
+// parts splits the amount into its sign, whole digits and fractional digits.
+func (m Money) parts() (sign, whole, frac string) {
+	exponent := Exponent(m.currency)
+
+	magnitude := uint64(m.minor)
+	if m.minor < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+
+	digits := strconv.FormatUint(magnitude, 10)
+	if exponent == 0 {
+		return sign, digits, ""
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	point := len(digits) - exponent
+	return sign, digits[:point], digits[point:]
+}
+
+func combine(m, o Money) (string, error) {
+	switch {
+	case m.currency == o.currency:
+		return m.currency, nil
+	case m == Money{}:
+		return o.currency, nil
+	case o == Money{}:
+		return m.currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+}
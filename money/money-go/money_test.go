@@ -0,0 +1,167 @@
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_Parse documents the rounding rules: amounts are exact to the
+// currency's minor unit and any further digits are rounded half to even.
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+	}{
+		{"12.34", "GBP", 1234},
+		{"12.3", "GBP", 1230},
+		{"12", "GBP", 1200},
+		{".5", "GBP", 50},
+		{"+1.00", "GBP", 100},
+		{"-0.01", "GBP", -1},
+		{"25360.05", "GBP", 2536005},
+		{"0.33", "GBP", 33},
+		{"3.33", "GBP", 333},
+
+		// half to even: ties go to the even neighbour
+		{"0.125", "GBP", 12},
+		{"0.135", "GBP", 14},
+		{"-0.125", "GBP", -12},
+		{"-0.135", "GBP", -14},
+		{"1.005", "GBP", 100},
+		{"1.015", "GBP", 102},
+
+		// anything past half rounds away from zero, anything under towards it
+		{"0.1251", "GBP", 13},
+		{"0.12500001", "GBP", 13},
+		{"0.1249999", "GBP", 12},
+		{"-0.1251", "GBP", -13},
+
+		// currencies without two decimal places
+		{"100", "JPY", 100},
+		{"100.5", "JPY", 100},
+		{"101.5", "JPY", 102},
+		{"1.2345", "KWD", 1234},
+		{"1.2355", "KWD", 1236},
+	}
+
+	for _, test := range tests {
+		t.Run(test.amount+" "+test.currency, func(t *testing.T) {
+			m, err := Parse(test.amount, test.currency)
+			require.NoError(t, err)
+			require.Equal(t, New(test.minor, test.currency), m)
+		})
+	}
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	for _, amount := range []string{"", ".", "-", "1.2.3", "abc", "1e2", "1,000.00", " 1", "NaN", "99999999999999999999"} {
+		_, err := Parse(amount, "GBP")
+		require.Error(t, err, amount)
+	}
+
+	for _, currency := range []string{"", "gbp", "GB", "POUND"} {
+		_, err := Parse("1.00", currency)
+		require.Error(t, err, currency)
+	}
+}
+
+func Test_Money_Amount(t *testing.T) {
+	tests := []struct {
+		money  Money
+		amount string
+	}{
+		{New(1234, "GBP"), "12.34"},
+		{New(5, "GBP"), "0.05"},
+		{New(-5, "GBP"), "-0.05"},
+		{New(0, "GBP"), "0.00"},
+		{New(1500, "JPY"), "1500"},
+		{New(1, "KWD"), "0.001"},
+		{New(math.MinInt64, "GBP"), "-92233720368547758.08"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.amount, test.money.Amount())
+	}
+
+	require.Equal(t, "12.34 GBP", New(1234, "GBP").String())
+}
+
+func Test_Money_Arithmetic(t *testing.T) {
+	a, b := MustParse("10.00", "GBP"), MustParse("2.50", "GBP")
+
+	tests := []struct {
+		name string
+		got  func() (Money, error)
+		want Money
+	}{
+		{"add", func() (Money, error) { return a.Add(b) }, MustParse("12.50", "GBP")},
+		{"sub", func() (Money, error) { return a.Sub(b) }, MustParse("7.50", "GBP")},
+		{"sub below zero", func() (Money, error) { return b.Sub(a) }, MustParse("-7.50", "GBP")},
+		{"neg", func() (Money, error) { return b.Neg() }, MustParse("-2.50", "GBP")},
+		{"mul", func() (Money, error) { return b.Mul(3) }, MustParse("7.50", "GBP")},
+		{"mul by zero", func() (Money, error) { return b.Mul(0) }, MustParse("0", "GBP")},
+		{"zero value adopts currency", func() (Money, error) { return Money{}.Add(b) }, b},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.got()
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_Money_Errors(t *testing.T) {
+	gbp, usd := MustParse("1", "GBP"), MustParse("1", "USD")
+
+	_, err := gbp.Add(usd)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = gbp.Sub(usd)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = gbp.Cmp(usd)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(math.MaxInt64, "GBP").Add(New(1, "GBP"))
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "GBP").Sub(New(1, "GBP"))
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "GBP").Neg()
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MaxInt64/2+1, "GBP").Mul(2)
+	require.ErrorIs(t, err, ErrOverflow)
+	_, err = New(math.MinInt64, "GBP").Mul(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+// Test_Sum totals the order the old money-go demo added up with float64.
+func Test_Sum(t *testing.T) {
+	total, err := Sum(
+		MustParse("25360.05", "GBP"),
+		MustParse("0.33", "GBP"),
+		MustParse("3.33", "GBP"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "25363.71 GBP", total.String())
+}
+
+func Test_Money_Cmp(t *testing.T) {
+	a, b := MustParse("1.00", "GBP"), MustParse("2.00", "GBP")
+
+	for _, test := range []struct {
+		x, y Money
+		want int
+	}{{a, b, -1}, {b, a, 1}, {a, a, 0}} {
+		got, err := test.x.Cmp(test.y)
+		require.NoError(t, err)
+		require.Equal(t, test.want, got)
+	}
+}
+
+func Test_Money_WithCurrency(t *testing.T) {
+	require.Equal(t, New(1230, "GBP"), New(1230, "").WithCurrency("GBP"))
+	require.Equal(t, New(1230, "JPY"), New(1230, "GBP").WithCurrency("JPY"), "minor units are not converted")
+}
@@ -0,0 +1,88 @@
+package money
+
+import (
+	"context"
+)
+
+// Order is a list of items to be paid for together.
+type Order struct {
+	Items []OrderItem
+}
+
+type OrderItem struct {
+	Price    Money
+	Quantity int64
+}
+
+// Total adds up the order's items, which must all be priced in the same
+// currency. Use TotalIn for orders that mix currencies.
+func (o Order) Total() (Money, error) {
+	var total Money
+	for _, item := range o.Items {
+		line, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			return Money{}, err
+		}
+
+		if total, err = total.Add(line); err != nil {
+			return Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// OrderTotal is an order's total in a single currency, along with the
+// conversions that went into it.
+type OrderTotal struct {
+	Total       Money        `json:"total"`
+	Conversions []Conversion `json:"conversions"`
+}
+
+// TotalIn adds up the order's items in currency. Items are subtotalled in
+// their own currency first and each subtotal converted once, so an order is
+// rounded once per currency rather than once per item.
+func (o Order) TotalIn(ctx context.Context, currency string, provider ExchangeRateProvider) (OrderTotal, error) {
+	var currencies []string
+	subtotals := map[string]Money{}
+	for _, item := range o.Items {
+		line, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			return OrderTotal{}, err
+		}
+
+		subtotal, seen := subtotals[line.currency]
+		if !seen {
+			currencies = append(currencies, line.currency)
+		}
+
+		if subtotals[line.currency], err = subtotal.Add(line); err != nil {
+			return OrderTotal{}, err
+		}
+	}
+
+	result := OrderTotal{Total: New(0, currency), Conversions: []Conversion{}}
+	for _, from := range currencies {
+		subtotal := subtotals[from]
+		if from == currency {
+			var err error
+			if result.Total, err = result.Total.Add(subtotal); err != nil {
+				return OrderTotal{}, err
+			}
+			continue
+		}
+
+		conversion, err := Exchange(ctx, provider, subtotal, currency)
+		if err != nil {
+			return OrderTotal{}, err
+		}
+
+		if result.Total, err = result.Total.Add(conversion.To); err != nil {
+			return OrderTotal{}, err
+		}
+
+		result.Conversions = append(result.Conversions, conversion)
+	}
+
+	return result, nil
+}
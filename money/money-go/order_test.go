@@ -0,0 +1,63 @@
+package money
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Order_Total(t *testing.T) {
+	order := Order{Items: []OrderItem{
+		{Price: MustParse("25360.05", "GBP"), Quantity: 1},
+		{Price: MustParse("0.33", "GBP"), Quantity: 1},
+		{Price: MustParse("3.33", "GBP"), Quantity: 1},
+	}}
+
+	total, err := order.Total()
+	require.NoError(t, err)
+	require.Equal(t, MustParse("25363.71", "GBP"), total)
+
+	order.Items = append(order.Items, OrderItem{Price: MustParse("1", "EUR"), Quantity: 1})
+	_, err = order.Total()
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func Test_Order_TotalIn(t *testing.T) {
+	ctx := context.Background()
+	rates, err := NewStaticRates(gbpEur("1.1523"))
+	require.NoError(t, err)
+
+	order := Order{Items: []OrderItem{
+		{Price: MustParse("25360.05", "GBP"), Quantity: 1},
+		{Price: MustParse("0.01", "EUR"), Quantity: 1},
+		{Price: MustParse("3.33", "GBP"), Quantity: 1},
+		{Price: MustParse("49.99", "EUR"), Quantity: 2},
+	}}
+
+	total, err := order.TotalIn(ctx, "GBP", rates)
+	require.NoError(t, err)
+
+	// the euro items are converted together: 99.99 / 1.1523 = 86.7743...
+	require.Equal(t, MustParse("25450.15", "GBP"), total.Total)
+	require.Equal(t, []Conversion{{
+		From: MustParse("99.99", "EUR"),
+		To:   MustParse("86.77", "GBP"),
+		Rate: gbpEur("1.1523"),
+	}}, total.Conversions)
+
+	total, err = order.TotalIn(ctx, "EUR", rates)
+	require.NoError(t, err)
+	require.Equal(t, MustParse("29326.21", "EUR"), total.Total)
+	require.Len(t, total.Conversions, 1)
+	require.Equal(t, MustParse("25363.38", "GBP"), total.Conversions[0].From)
+
+	empty, err := Order{}.TotalIn(ctx, "GBP", rates)
+	require.NoError(t, err)
+	require.Equal(t, MustParse("0", "GBP"), empty.Total)
+	require.Empty(t, empty.Conversions)
+
+	order.Items = append(order.Items, OrderItem{Price: MustParse("1", "USD"), Quantity: 1})
+	_, err = order.TotalIn(ctx, "GBP", rates)
+	require.ErrorIs(t, err, ErrNoRate)
+}
@@ -0,0 +1,151 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Rate is an exact proportion, such as a tax or discount rate. Rates are
+// parsed from decimal strings so that 17.5% is exactly 7/40.
+type Rate struct {
+	r *big.Rat
+}
+
+// ParseRate parses a rate written as a decimal fraction ("0.2") or a
+// percentage ("20%").
+func ParseRate(s string) (Rate, error) {
+	percent := strings.HasSuffix(s, "%")
+	decimal := strings.TrimSuffix(s, "%")
+
+	// big.Rat also accepts fractions and exponents, which a rate should not be
+	// written as
+	if strings.ContainsAny(decimal, "/eE") {
+		return Rate{}, fmt.Errorf("money: invalid rate %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(decimal)
+	if !ok {
+		return Rate{}, fmt.Errorf("money: invalid rate %q", s)
+	}
+
+	if percent {
+		r.Quo(r, big.NewRat(100, 1))
+	}
+
+	return Rate{r: r}, nil
+}
+
+// MustParseRate is like ParseRate but panics if s is invalid.
+func MustParseRate(s string) Rate {
+	rate, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return rate
+}
+
+func (r Rate) rat() *big.Rat {
+	if r.r == nil {
+		return new(big.Rat)
+	}
+
+	return r.r
+}
+
+func (r Rate) String() string {
+	return r.rat().FloatString(4)
+}
+
+// Decimal returns the rate as an exact decimal fraction, e.g. "1.16745".
+func (r Rate) Decimal() string {
+	// a parsed rate's denominator only has the factors 2 and 5, so it
+	// terminates after as many places as the larger of the two powers
+	denom := new(big.Int).Set(r.rat().Denom())
+	places := 0
+	for _, factor := range []int64{2, 5} {
+		f, rem := big.NewInt(factor), new(big.Int)
+		power := 0
+		for {
+			quo, _ := new(big.Int).QuoRem(denom, f, rem)
+			if rem.Sign() != 0 {
+				break
+			}
+			denom, power = quo, power+1
+		}
+		places = max(places, power)
+	}
+
+	return r.rat().FloatString(places)
+}
+
+// MarshalText writes the rate in its exact decimal form.
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.Decimal()), nil
+}
+
+// UnmarshalText accepts anything ParseRate does.
+func (r *Rate) UnmarshalText(text []byte) error {
+	rate, err := ParseRate(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = rate
+	return nil
+}
+
+// MulRate returns m multiplied by rate, rounded half to even to the
+// currency's minor unit.
+func (m Money) MulRate(rate Rate) (Money, error) {
+	product := new(big.Rat).SetInt64(m.minor)
+	product.Mul(product, rate.rat())
+
+	minor, err := roundHalfEven(product)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{minor: minor, currency: m.currency}, nil
+}
+
+// Tax returns the tax due on a net amount at rate, e.g. 20% of 10.00 is
+// 2.00.
+func (m Money) Tax(rate Rate) (Money, error) {
+	return m.MulRate(rate)
+}
+
+// AddTax returns the gross amount: m plus the tax due on it at rate.
+func (m Money) AddTax(rate Rate) (Money, error) {
+	tax, err := m.Tax(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m.Add(tax)
+}
+
+// IncludedTax returns the tax contained in a gross amount that already
+// includes tax at rate, e.g. 2.00 of a 12.00 price including 20% VAT.
+func (m Money) IncludedTax(rate Rate) (Money, error) {
+	// gross * rate / (1 + rate)
+	divisor := new(big.Rat).Add(big.NewRat(1, 1), rate.rat())
+	if divisor.Sign() == 0 {
+		return Money{}, fmt.Errorf("money: invalid tax rate %s", rate)
+	}
+
+	return m.MulRate(Rate{r: new(big.Rat).Quo(rate.rat(), divisor)})
+}
+
+// Discount returns m reduced by rate, e.g. 10% off 9.99 is 8.99. The discount
+// itself is rounded, not the result, so the discount and the discounted amount
+// always add back up to m.
+func (m Money) Discount(rate Rate) (Money, error) {
+	discount, err := m.MulRate(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m.Sub(discount)
+}
@@ -0,0 +1,88 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRate(t *testing.T) {
+	for _, test := range []struct{ in, want string }{
+		{"0.2", "0.2000"},
+		{"20%", "0.2000"},
+		{"17.5%", "0.1750"},
+		{"0", "0.0000"},
+	} {
+		rate, err := ParseRate(test.in)
+		require.NoError(t, err)
+		require.Equal(t, test.want, rate.String())
+	}
+
+	for _, in := range []string{"", "%", "abc", "1/3", "1e2", "20%%"} {
+		_, err := ParseRate(in)
+		require.Error(t, err, in)
+	}
+}
+
+func Test_Money_Tax(t *testing.T) {
+	tests := []struct {
+		net, rate, tax, gross string
+	}{
+		{"10.00", "20%", "2.00", "12.00"},
+		{"9.99", "20%", "2.00", "11.99"},
+		{"0.99", "17.5%", "0.17", "1.16"},
+
+		// 0.1 * 0.25 = 0.025, a tie rounded to the even 0.02
+		{"0.10", "25%", "0.02", "0.12"},
+		// 0.3 * 0.25 = 0.075, a tie rounded to the even 0.08
+		{"0.30", "25%", "0.08", "0.38"},
+		{"-10.00", "20%", "-2.00", "-12.00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.net+" at "+test.rate, func(t *testing.T) {
+			net := MustParse(test.net, "GBP")
+			rate := MustParseRate(test.rate)
+
+			tax, err := net.Tax(rate)
+			require.NoError(t, err)
+			require.Equal(t, MustParse(test.tax, "GBP"), tax)
+
+			gross, err := net.AddTax(rate)
+			require.NoError(t, err)
+			require.Equal(t, MustParse(test.gross, "GBP"), gross)
+		})
+	}
+}
+
+func Test_Money_IncludedTax(t *testing.T) {
+	tax, err := MustParse("12.00", "GBP").IncludedTax(MustParseRate("20%"))
+	require.NoError(t, err)
+	require.Equal(t, MustParse("2.00", "GBP"), tax)
+
+	// 25363.71 / 6 = 4227.285, a tie rounded to the even 4227.28
+	tax, err = MustParse("25363.71", "GBP").IncludedTax(MustParseRate("20%"))
+	require.NoError(t, err)
+	require.Equal(t, MustParse("4227.28", "GBP"), tax)
+
+	_, err = MustParse("1", "GBP").IncludedTax(MustParseRate("-100%"))
+	require.Error(t, err)
+}
+
+func Test_Money_Discount(t *testing.T) {
+	tests := []struct {
+		price, rate, want string
+	}{
+		{"9.99", "10%", "8.99"},
+		{"100.00", "33%", "67.00"},
+		{"0.05", "50%", "0.03"},
+		{"19.99", "0", "19.99"},
+		{"19.99", "100%", "0.00"},
+	}
+
+	for _, test := range tests {
+		got, err := MustParse(test.price, "GBP").Discount(MustParseRate(test.rate))
+		require.NoError(t, err)
+		require.Equal(t, MustParse(test.want, "GBP"), got, "%s off %s", test.rate, test.price)
+	}
+}
@@ -0,0 +1,204 @@
+package money
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileRates is an ExchangeRateProvider backed by a snapshot of rates in a JSON
+// or CSV file, chosen by the file's extension. The file is read when the
+// provider is opened and again on Reload; a snapshot that fails to load leaves
+// the previous rates in place.
+//
+// A JSON snapshot applies one timestamp and source to all of its rates unless
+// a rate gives its own:
+//
+//	{
+//	  "asOf": "2026-10-16T16:00:00Z",
+//	  "source": "ECB",
+//	  "rates": [{"from": "GBP", "to": "EUR", "rate": "1.1523"}]
+//	}
+//
+// A CSV snapshot has a header row naming its columns; as_of and source are
+// optional and default to the file's modification time and name:
+//
+//	from,to,rate,as_of
+//	GBP,EUR,1.1523,2026-10-16T16:00:00Z
+type FileRates struct {
+	path string
+
+	mu    sync.RWMutex
+	rates *StaticRates
+}
+
+var _ ExchangeRateProvider = (*FileRates)(nil)
+
+// OpenRates loads the snapshot at path.
+func OpenRates(path string) (*FileRates, error) {
+	f := &FileRates{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload reads the snapshot again, e.g. after it has been replaced with a
+// newer one.
+func (f *FileRates) Reload() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	defaults := ExchangeRate{AsOf: info.ModTime().UTC(), Source: filepath.Base(f.path)}
+
+	var rates []ExchangeRate
+	switch ext := strings.ToLower(filepath.Ext(f.path)); ext {
+	case ".json":
+		rates, err = ReadRatesJSON(file, defaults)
+	case ".csv":
+		rates, err = ReadRatesCSV(file, defaults)
+	default:
+		err = fmt.Errorf("unsupported snapshot format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("money: %s: %w", f.path, err)
+	}
+
+	table, err := NewStaticRates(rates...)
+	if err != nil {
+		return fmt.Errorf("money: %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	f.rates = table
+	f.mu.Unlock()
+
+	return nil
+}
+
+func (f *FileRates) Rate(ctx context.Context, from, to string) (ExchangeRate, error) {
+	f.mu.RLock()
+	rates := f.rates
+	f.mu.RUnlock()
+
+	return rates.Rate(ctx, from, to)
+}
+
+type jsonSnapshot struct {
+	AsOf   time.Time      `json:"asOf"`
+	Source string         `json:"source"`
+	Rates  []ExchangeRate `json:"rates"`
+}
+
+// ReadRatesJSON reads a JSON snapshot. Rates without a timestamp or source
+// take the snapshot's, and failing that the ones in defaults.
+func ReadRatesJSON(r io.Reader, defaults ExchangeRate) ([]ExchangeRate, error) {
+	var snapshot jsonSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+
+	if !snapshot.AsOf.IsZero() {
+		defaults.AsOf = snapshot.AsOf
+	}
+	if snapshot.Source != "" {
+		defaults.Source = snapshot.Source
+	}
+
+	for i := range snapshot.Rates {
+		snapshot.Rates[i] = withDefaults(snapshot.Rates[i], defaults)
+	}
+
+	return snapshot.Rates, nil
+}
+
+// ReadRatesCSV reads a CSV snapshot. Rates without a timestamp or source take
+// the ones in defaults.
+func ReadRatesCSV(r io.Reader, defaults ExchangeRate) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"from", "to", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %q column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rates []ExchangeRate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		rate := ExchangeRate{
+			From:   field(record, "from"),
+			To:     field(record, "to"),
+			Source: field(record, "source"),
+		}
+
+		if rate.Rate, err = ParseRate(field(record, "rate")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if asOf := field(record, "as_of"); asOf != "" {
+			if rate.AsOf, err = time.Parse(time.RFC3339, asOf); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		rates = append(rates, withDefaults(rate, defaults))
+	}
+
+	return rates, nil
+}
+
+func withDefaults(rate, defaults ExchangeRate) ExchangeRate {
+	if rate.AsOf.IsZero() {
+		rate.AsOf = defaults.AsOf
+	}
+	if rate.Source == "" {
+		rate.Source = defaults.Source
+	}
+
+	return rate
+}
@@ -0,0 +1,108 @@
+package money
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSnapshot(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func Test_FileRates_JSON(t *testing.T) {
+	path := writeSnapshot(t, "rates.json", `{
+		"asOf": "2026-10-16T16:00:00Z",
+		"source": "ECB",
+		"rates": [
+			{"from": "GBP", "to": "EUR", "rate": "1.1523"},
+			{"from": "GBP", "to": "USD", "rate": "1.3378", "asOf": "2026-10-17T09:00:00Z", "source": "Fed"}
+		]
+	}`)
+
+	rates, err := OpenRates(path)
+	require.NoError(t, err)
+
+	got, err := rates.Rate(context.Background(), "EUR", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, gbpEur("1.1523").Rate.Decimal(), got.Rate.Decimal())
+	require.Equal(t, asOf, got.AsOf)
+	require.Equal(t, "ECB", got.Source)
+
+	got, err = rates.Rate(context.Background(), "GBP", "USD")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC), got.AsOf)
+	require.Equal(t, "Fed", got.Source)
+}
+
+func Test_FileRates_CSV(t *testing.T) {
+	path := writeSnapshot(t, "rates.csv", strings.Join([]string{
+		"from, to, rate, as_of",
+		"GBP, EUR, 1.1523, 2026-10-16T16:00:00Z",
+		"GBP, USD, 1.3378,",
+	}, "\n"))
+
+	modified := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modified, modified))
+
+	rates, err := OpenRates(path)
+	require.NoError(t, err)
+
+	got, err := rates.Rate(context.Background(), "GBP", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "1.1523", got.Rate.Decimal())
+	require.Equal(t, asOf, got.AsOf)
+	require.Equal(t, "rates.csv", got.Source)
+
+	// rates without a timestamp are as old as the file
+	got, err = rates.Rate(context.Background(), "GBP", "USD")
+	require.NoError(t, err)
+	require.Equal(t, modified, got.AsOf)
+}
+
+func Test_FileRates_Reload(t *testing.T) {
+	path := writeSnapshot(t, "rates.csv", "from,to,rate\nGBP,EUR,1.15\n")
+
+	rates, err := OpenRates(path)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("from,to,rate\nGBP,EUR,1.16\n"), 0o644))
+	require.NoError(t, rates.Reload())
+
+	got, err := rates.Rate(context.Background(), "GBP", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "1.16", got.Rate.Decimal())
+
+	// a broken snapshot is reported and the last good one kept
+	require.NoError(t, os.WriteFile(path, []byte("from,to,rate\nGBP,EUR,lots\n"), 0o644))
+	require.ErrorContains(t, rates.Reload(), "line 2")
+
+	got, err = rates.Rate(context.Background(), "GBP", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "1.16", got.Rate.Decimal())
+}
+
+func Test_OpenRates_Invalid(t *testing.T) {
+	for name, contents := range map[string]string{
+		"rates.txt":     "GBP EUR 1.15",
+		"missing.csv":   "from,rate\nGBP,1.15\n",
+		"pair.csv":      "from,to,rate\nGBP,GBP,1\n",
+		"negative.csv":  "from,to,rate\nGBP,EUR,-1.15\n",
+		"broken.json":   `{"rates": [`,
+		"number.json":   `{"rates": [{"from": "GBP", "to": "EUR", "rate": 1.15}]}`,
+		"timestamp.csv": "from,to,rate,as_of\nGBP,EUR,1.15,yesterday\n",
+	} {
+		_, err := OpenRates(writeSnapshot(t, name, contents))
+		require.Error(t, err, name)
+	}
+
+	_, err := OpenRates(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,109 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// parseMinor converts a decimal string to a whole number of minor units with
+// exponent decimal places, rounding any further digits half to even.
+func parseMinor(amount string, exponent int) (int64, error) {
+	invalid := fmt.Errorf("money: invalid amount %q", amount)
+
+	s := amount
+	negative := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !digits(whole) || !digits(frac) {
+		return 0, invalid
+	}
+
+	var rest string
+	if len(frac) > exponent {
+		frac, rest = frac[:exponent], frac[exponent:]
+	} else {
+		frac += strings.Repeat("0", exponent-len(frac))
+	}
+
+	units := strings.TrimLeft(whole+frac, "0")
+	if units == "" {
+		units = "0"
+	}
+
+	minor, err := strconv.ParseUint(units, 10, 63)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+
+	if roundUp(rest, minor%2 == 1) {
+		minor++
+		if minor > math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+	}
+
+	if negative {
+		return -int64(minor), nil
+	}
+
+	return int64(minor), nil
+}
+
+// roundUp reports whether the discarded digits rest round the kept digits up,
+// rounding half to even.
+func roundUp(rest string, odd bool) bool {
+	if rest == "" || rest[0] < '5' {
+		return false
+	}
+
+	if rest[0] > '5' || strings.TrimRight(rest[1:], "0") != "" {
+		return true
+	}
+
+	// exactly half
+	return odd
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// roundHalfEven rounds r to the nearest integer, ties to even.
+func roundHalfEven(r *big.Rat) (int64, error) {
+	num, denom := r.Num(), r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, denom, new(big.Int))
+
+	// compare twice the remainder with the denominator to find which side of
+	// half the fraction is on
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+
+	switch c := twice.Cmp(denom); {
+	case c > 0, c == 0 && quo.Bit(0) == 1:
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, ErrOverflow
+	}
+
+	return quo.Int64(), nil
+}
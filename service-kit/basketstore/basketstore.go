@@ -0,0 +1,74 @@
+// Package basketstore persists the baskets of the http, grpc and graphql
+// basket-services. Each service keeps its own basket model, so the stores are
+// generic over it and a Codec tells them how to identify and encode a basket.
+package basketstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned when a basket does not exist or has expired.
+	ErrNotFound = errors.New("basket not found")
+
+	// ErrAlreadyExists is returned by Create when a live basket already has
+	// the same id.
+	ErrAlreadyExists = errors.New("basket already exists")
+)
+
+// Store persists baskets of type B.
+//
+// Baskets expire after the time to live the store was created with. Create and
+// Update (re)start that clock; Get and ListByOwner do not, so a basket that is
+// only ever read still expires. Expired baskets behave exactly like baskets
+// that were never created.
+type Store[B any] interface {
+	Get(ctx context.Context, id uuid.UUID) (*B, error)
+	Create(ctx context.Context, basket *B) error
+	Update(ctx context.Context, basket *B) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListByOwner(ctx context.Context, ownerId string) ([]B, error)
+}
+
+// Codec describes a basket type to the stores.
+type Codec[B any] struct {
+	// ID returns the basket's id.
+	ID func(basket *B) uuid.UUID
+
+	// Owner returns the id of the basket's owner, or "" for an anonymous
+	// basket.
+	Owner func(basket *B) string
+
+	// Marshal encodes the basket as stored and Unmarshal decodes it again.
+	// The encoding must be JSON for the PostgresStore's jsonb column.
+	Marshal   func(basket *B) ([]byte, error)
+	Unmarshal func(data []byte, basket *B) error
+}
+
+// JSONCodec is a Codec that stores baskets as plain JSON.
+func JSONCodec[B any](id func(basket *B) uuid.UUID, owner func(basket *B) string) Codec[B] {
+	return Codec[B]{
+		ID:    id,
+		Owner: owner,
+		Marshal: func(basket *B) ([]byte, error) {
+			return json.Marshal(basket)
+		},
+		Unmarshal: func(data []byte, basket *B) error {
+			return json.Unmarshal(data, basket)
+		},
+	}
+}
+
+// decode is Codec.Unmarshal into a new basket.
+func (c Codec[B]) decode(data []byte) (*B, error) {
+	var basket B
+	if err := c.Unmarshal(data, &basket); err != nil {
+		return nil, err
+	}
+
+	return &basket, nil
+}
@@ -0,0 +1,21 @@
+package basketstore
+
+import (
+	"github.com/google/uuid"
+)
+
+type testItem struct {
+	ProductId uuid.UUID `json:"productId"`
+	Quantity  uint      `json:"quantity"`
+}
+
+type testBasket struct {
+	Id      uuid.UUID  `json:"id"`
+	OwnerId string     `json:"ownerId,omitempty"`
+	Items   []testItem `json:"items"`
+}
+
+var testCodec = JSONCodec(
+	func(b *testBasket) uuid.UUID { return b.Id },
+	func(b *testBasket) string { return b.OwnerId },
+)
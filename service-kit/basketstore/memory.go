@@ -0,0 +1,137 @@
+package basketstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryEntry struct {
+	data      []byte
+	ownerId   string
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store held in process memory. It is intended for tests and
+// local runs; nothing survives a restart. Baskets are kept encoded, like the
+// other stores keep them, so callers never share one with the store.
+type MemoryStore[B any] struct {
+	codec Codec[B]
+
+	mu      sync.RWMutex
+	ttl     time.Duration
+	baskets map[uuid.UUID]memoryEntry
+
+	// now is swapped out in tests to control expiry.
+	now func() time.Time
+}
+
+func NewMemoryStore[B any](codec Codec[B], ttl time.Duration) *MemoryStore[B] {
+	return &MemoryStore[B]{
+		codec:   codec,
+		ttl:     ttl,
+		baskets: make(map[uuid.UUID]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore[B]) Get(ctx context.Context, id uuid.UUID) (*B, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.live(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return s.codec.decode(entry.data)
+}
+
+func (s *MemoryStore[B]) Create(ctx context.Context, basket *B) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.live(s.codec.ID(basket)); ok {
+		return ErrAlreadyExists
+	}
+
+	return s.put(basket)
+}
+
+func (s *MemoryStore[B]) Update(ctx context.Context, basket *B) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.live(s.codec.ID(basket)); !ok {
+		return ErrNotFound
+	}
+
+	return s.put(basket)
+}
+
+func (s *MemoryStore[B]) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.live(id); !ok {
+		return ErrNotFound
+	}
+
+	delete(s.baskets, id)
+	return nil
+}
+
+func (s *MemoryStore[B]) ListByOwner(ctx context.Context, ownerId string) ([]B, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baskets := []B{}
+	for id, entry := range s.baskets {
+		if entry.ownerId != ownerId {
+			continue
+		}
+
+		if _, ok := s.live(id); !ok {
+			continue
+		}
+
+		basket, err := s.codec.decode(entry.data)
+		if err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, *basket)
+	}
+
+	return baskets, nil
+}
+
+// live returns the entry for id if it exists and has not expired. Callers
+// must hold the lock.
+func (s *MemoryStore[B]) live(id uuid.UUID) (memoryEntry, bool) {
+	entry, ok := s.baskets[id]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return memoryEntry{}, false
+	}
+
+	return entry, true
+}
+
+// put stores basket and restarts its time to live. Callers must hold the
+// write lock.
+func (s *MemoryStore[B]) put(basket *B) error {
+	data, err := s.codec.Marshal(basket)
+	if err != nil {
+		return err
+	}
+
+	s.baskets[s.codec.ID(basket)] = memoryEntry{
+		data:      data,
+		ownerId:   s.codec.Owner(basket),
+		expiresAt: s.now().Add(s.ttl),
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package basketstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MemoryStore_CreateAndGet(t *testing.T) {
+	s := NewMemoryStore(testCodec, time.Hour)
+	ctx := context.Background()
+
+	basket := testBasket{
+		Id:    uuid.New(),
+		Items: []testItem{{ProductId: uuid.New(), Quantity: 2}},
+	}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.ErrorIs(t, s.Create(ctx, &basket), ErrAlreadyExists)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, basket, *got)
+
+	// the stored basket must not alias the caller's copy
+	got.Items[0].Quantity = 10
+	again, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, uint(2), again.Items[0].Quantity)
+}
+
+func Test_MemoryStore_TTL(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore(testCodec, time.Hour)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	basket := testBasket{Id: uuid.New()}
+	require.NoError(t, s.Create(ctx, &basket))
+
+	// reads do not extend the time to live, writes do
+	now = now.Add(59 * time.Minute)
+	_, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.NoError(t, s.Update(ctx, &basket))
+
+	now = now.Add(59 * time.Minute)
+	_, err = s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = s.Get(ctx, basket.Id)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Update(ctx, &basket), ErrNotFound)
+	require.ErrorIs(t, s.Delete(ctx, basket.Id), ErrNotFound)
+
+	// an expired id can be reused
+	require.NoError(t, s.Create(ctx, &basket))
+}
+
+func Test_MemoryStore_ListByOwner(t *testing.T) {
+	s := NewMemoryStore(testCodec, time.Hour)
+	ctx := context.Background()
+
+	mine := testBasket{Id: uuid.New(), OwnerId: "alice", Items: []testItem{}}
+	theirs := testBasket{Id: uuid.New(), OwnerId: "bob"}
+	anonymous := testBasket{Id: uuid.New()}
+	for _, b := range []*testBasket{&mine, &theirs, &anonymous} {
+		require.NoError(t, s.Create(ctx, b))
+	}
+
+	baskets, err := s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, []testBasket{mine}, baskets)
+
+	require.NoError(t, s.Delete(ctx, mine.Id))
+	baskets, err = s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, baskets)
+}
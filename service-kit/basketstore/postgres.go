@@ -0,0 +1,155 @@
+package basketstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS baskets (
+	id         uuid PRIMARY KEY,
+	owner_id   text,
+	data       jsonb NOT NULL,
+	expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS baskets_owner_id_idx ON baskets (owner_id);
+`
+
+// PostgresStore is a Store backed by a "baskets" table. Expired rows are
+// ignored by every query and overwritten when a basket with the same id is
+// created again.
+type PostgresStore[B any] struct {
+	codec Codec[B]
+	pool  *pgxpool.Pool
+	ttl   time.Duration
+}
+
+// NewPostgresStore creates the baskets table if it does not already exist.
+func NewPostgresStore[B any](ctx context.Context, codec Codec[B], pool *pgxpool.Pool, ttl time.Duration) (*PostgresStore[B], error) {
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore[B]{codec: codec, pool: pool, ttl: ttl}, nil
+}
+
+func (s *PostgresStore[B]) Get(ctx context.Context, id uuid.UUID) (*B, error) {
+	var data []byte
+	err := s.pool.QueryRow(ctx,
+		`SELECT data FROM baskets WHERE id = $1 AND expires_at > now()`,
+		id,
+	).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.codec.decode(data)
+}
+
+func (s *PostgresStore[B]) Create(ctx context.Context, basket *B) error {
+	data, err := s.codec.Marshal(basket)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO baskets (id, owner_id, data, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (id) DO UPDATE
+			SET owner_id = EXCLUDED.owner_id, data = EXCLUDED.data, expires_at = EXCLUDED.expires_at
+			WHERE baskets.expires_at <= now()`,
+		s.codec.ID(basket), ownerOrNull(s.codec.Owner(basket)), data, s.ttl.Seconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *PostgresStore[B]) Update(ctx context.Context, basket *B) error {
+	data, err := s.codec.Marshal(basket)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE baskets SET owner_id = $2, data = $3, expires_at = now() + make_interval(secs => $4)
+		WHERE id = $1 AND expires_at > now()`,
+		s.codec.ID(basket), ownerOrNull(s.codec.Owner(basket)), data, s.ttl.Seconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore[B]) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM baskets WHERE id = $1 AND expires_at > now()`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore[B]) ListByOwner(ctx context.Context, ownerId string) ([]B, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT data FROM baskets WHERE owner_id = $1 AND expires_at > now()`,
+		ownerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baskets := []B{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		basket, err := s.codec.decode(data)
+		if err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, *basket)
+	}
+
+	return baskets, rows.Err()
+}
+
+func ownerOrNull(ownerId string) *string {
+	if ownerId == "" {
+		return nil
+	}
+
+	return &ownerId
+}
@@ -0,0 +1,151 @@
+package basketstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store backed by Redis. Each basket is stored in its encoded
+// form under "basket:{id}" with the store's TTL, and baskets with an owner are
+// indexed in a set under "basket:owner:{ownerId}".
+type RedisStore[B any] struct {
+	codec Codec[B]
+	rdb   *redis.Client
+	ttl   time.Duration
+}
+
+func NewRedisStore[B any](codec Codec[B], rdb *redis.Client, ttl time.Duration) *RedisStore[B] {
+	return &RedisStore[B]{codec: codec, rdb: rdb, ttl: ttl}
+}
+
+func basketKey(id uuid.UUID) string {
+	return fmt.Sprintf("basket:%s", id)
+}
+
+func ownerKey(ownerId string) string {
+	return fmt.Sprintf("basket:owner:%s", ownerId)
+}
+
+func (s *RedisStore[B]) Get(ctx context.Context, id uuid.UUID) (*B, error) {
+	data, err := s.rdb.Get(ctx, basketKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.codec.decode(data)
+}
+
+func (s *RedisStore[B]) Create(ctx context.Context, basket *B) error {
+	return s.set(ctx, basket, "NX", ErrAlreadyExists)
+}
+
+func (s *RedisStore[B]) Update(ctx context.Context, basket *B) error {
+	return s.set(ctx, basket, "XX", ErrNotFound)
+}
+
+// set writes the basket with the given SET mode, returning failed if the mode
+// stopped the write, and indexes it against its owner.
+func (s *RedisStore[B]) set(ctx context.Context, basket *B, mode string, failed error) error {
+	data, err := s.codec.Marshal(basket)
+	if err != nil {
+		return err
+	}
+
+	err = s.rdb.SetArgs(ctx, basketKey(s.codec.ID(basket)), data, redis.SetArgs{Mode: mode, TTL: s.ttl}).Err()
+	if errors.Is(err, redis.Nil) {
+		return failed
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.index(ctx, basket)
+}
+
+func (s *RedisStore[B]) Delete(ctx context.Context, id uuid.UUID) error {
+	basket, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, basketKey(id))
+		if owner := s.codec.Owner(basket); owner != "" {
+			pipe.SRem(ctx, ownerKey(owner), id.String())
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore[B]) ListByOwner(ctx context.Context, ownerId string) ([]B, error) {
+	ids, err := s.rdb.SMembers(ctx, ownerKey(ownerId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	baskets := []B{}
+	if len(ids) == 0 {
+		return baskets, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("basket:%s", id)
+	}
+
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []any
+	for i, value := range values {
+		// the basket expired but its index entry is still around
+		if value == nil {
+			expired = append(expired, ids[i])
+			continue
+		}
+
+		basket, err := s.codec.decode([]byte(value.(string)))
+		if err != nil {
+			return nil, err
+		}
+
+		baskets = append(baskets, *basket)
+	}
+
+	if len(expired) > 0 {
+		if err := s.rdb.SRem(ctx, ownerKey(ownerId), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return baskets, nil
+}
+
+// index records the basket against its owner. The index lives as long as the
+// newest basket in it; stale members are pruned by ListByOwner.
+func (s *RedisStore[B]) index(ctx context.Context, basket *B) error {
+	owner := s.codec.Owner(basket)
+	if owner == "" {
+		return nil
+	}
+
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, ownerKey(owner), s.codec.ID(basket).String())
+		pipe.Expire(ctx, ownerKey(owner), s.ttl)
+		return nil
+	})
+
+	return err
+}
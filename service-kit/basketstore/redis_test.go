@@ -0,0 +1,49 @@
+package basketstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	s := NewRedisStore(testCodec, rdb, time.Hour)
+	ctx := context.Background()
+
+	basket := testBasket{
+		Id:      uuid.New(),
+		OwnerId: "alice",
+		Items:   []testItem{{ProductId: uuid.New(), Quantity: 2}},
+	}
+	require.NoError(t, s.Create(ctx, &basket))
+	require.ErrorIs(t, s.Create(ctx, &basket), ErrAlreadyExists)
+
+	got, err := s.Get(ctx, basket.Id)
+	require.NoError(t, err)
+	require.Equal(t, basket, *got)
+
+	baskets, err := s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, []testBasket{basket}, baskets)
+
+	require.NoError(t, s.Delete(ctx, basket.Id))
+	baskets, err = s.ListByOwner(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, baskets)
+
+	require.NoError(t, s.Create(ctx, &basket))
+	mr.FastForward(time.Hour)
+
+	_, err = s.Get(ctx, basket.Id)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, s.Update(ctx, &basket), ErrNotFound)
+}
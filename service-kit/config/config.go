@@ -0,0 +1,454 @@
+// Package config loads a service's configuration into a typed struct.
+//
+// Each value comes from, in increasing order of precedence: the default in
+// its struct tag, an optional YAML file, an environment variable and a
+// command-line flag. Everything is validated before Load returns, and every
+// missing or invalid value is reported at once rather than one per restart.
+//
+// Fields are described with struct tags:
+//
+//	type Config struct {
+//		Store string `yaml:"store" env:"BASKET_STORE" default:"redis" oneof:"redis postgres memory"`
+//		Redis struct {
+//			Host     string `yaml:"host" env:"REDIS_HOST" required:"true"`
+//			Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+//		} `yaml:"redis"`
+//	}
+//
+// A field's key is its path of yaml names, such as "redis.host". The key is
+// also the name of its flag, so -redis.host=localhost sets it. The YAML file
+// is named by the -config flag or CONFIG_FILE.
+//
+// Fields can be strings, bools, numbers, time.Durations, comma separated
+// []strings, or any type that implements encoding.TextUnmarshaler. A config
+// with a Validate() error method has it called to check anything tags cannot
+// express, such as values only required in some modes.
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// FileEnv is the environment variable naming the YAML file, if the -config
+// flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// redacted replaces the value of secrets in String and Redacted.
+const redacted = "********"
+
+// Error lists every problem found while loading a config.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// field is one value in a config struct.
+type field struct {
+	key      string
+	env      string
+	def      *string
+	required bool
+	secret   bool
+	oneof    []string
+	value    reflect.Value
+}
+
+// Load fills cfg, which must be a pointer to a struct, from its defaults, the
+// YAML file, the environment and args, the command-line arguments without the
+// program name. It returns an *Error listing every problem with the result,
+// or flag.ErrHelp if args asked for usage.
+func Load(cfg any, args []string) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Load needs a pointer to a struct, not %T", cfg)
+	}
+
+	fields := walk(v.Elem(), "")
+
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, f := range fields {
+		if f.def == nil {
+			continue
+		}
+		if err := set(f.value, *f.def); err != nil {
+			problem("%s: invalid default %q: %v", f.key, *f.def, err)
+		}
+	}
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	file := flags.String("config", os.Getenv(FileEnv), "YAML `file` to load the config from (env "+FileEnv+")")
+
+	flagged := map[string]string{}
+	for _, f := range fields {
+		flags.Var(&flagValue{field: f, flagged: flagged}, f.key, usage(f))
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file != "" {
+		if err := loadFile(*file, cfg); err != nil {
+			problem("%s: %v", *file, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if s := os.Getenv(f.env); s != "" {
+			if err := set(f.value, s); err != nil {
+				problem("%s: invalid value %q: %v", f.env, s, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if s, ok := flagged[f.key]; ok {
+			if err := set(f.value, s); err != nil {
+				problem("-%s: invalid value %q: %v", f.key, s, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			problem("%s is required%s", f.key, from(f))
+		}
+		if len(f.oneof) > 0 && !f.value.IsZero() && !contains(f.oneof, f.value.String()) {
+			problem("%s must be one of %s, not %q", f.key, strings.Join(f.oneof, ", "), f.value.String())
+		}
+	}
+
+	if validator, ok := cfg.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			for _, err := range unjoin(err) {
+				problem("%v", err)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+
+	return nil
+}
+
+// LoadOrExit loads cfg from the program's arguments, for a service's main. It
+// exits if usage was asked for, and with every problem logged if cfg is
+// invalid. The loaded config is logged with its secrets redacted.
+func LoadOrExit(cfg any) {
+	err := Load(cfg, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	var invalid *Error
+	if errors.As(err, &invalid) {
+		slog.Error("Invalid config", slog.Any("problems", invalid.Problems))
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("Failed to load config", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	slog.Info("Loaded config", slog.Any("config", Redacted(cfg)))
+}
+
+// Required returns an error saying the value at key, which env also sets, is
+// required if it is empty. It is for Validate methods, for values that are
+// only sometimes required.
+func Required(key, env, value string) error {
+	if value != "" {
+		return nil
+	}
+
+	return fmt.Errorf("%s is required%s", key, from(field{env: env}))
+}
+
+// String describes cfg as space separated key=value pairs, in the order of
+// its fields, with secrets redacted. It is for logging the effective config.
+func String(cfg any) string {
+	var b strings.Builder
+	for i, f := range walk(addressable(cfg), "") {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%s", f.key, display(f))
+	}
+
+	return b.String()
+}
+
+// Redacted returns cfg's values by key, with secrets redacted. It is for
+// logging the effective config as structured data.
+func Redacted(cfg any) map[string]string {
+	values := map[string]string{}
+	for _, f := range walk(addressable(cfg), "") {
+		values[f.key] = display(f)
+	}
+
+	return values
+}
+
+// addressable returns the struct cfg, or cfg points to, as a value whose fields
+// can be addressed.
+func addressable(cfg any) reflect.Value {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Pointer {
+		return v.Elem()
+	}
+
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
+
+func display(f field) string {
+	if f.secret && !f.value.IsZero() {
+		return redacted
+	}
+
+	if s, ok := f.value.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	if f.value.Kind() == reflect.Slice {
+		return strings.Join(f.value.Interface().([]string), ",")
+	}
+
+	return fmt.Sprint(f.value.Interface())
+}
+
+// walk lists the fields of the struct v, descending into nested structs that
+// are not values in their own right.
+func walk(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !scalar(fv) {
+			fields = append(fields, walk(fv, key)...)
+			continue
+		}
+
+		f := field{
+			key:      key,
+			env:      sf.Tag.Get("env"),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+			oneof:    strings.Fields(sf.Tag.Get("oneof")),
+			value:    fv,
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			f.def = &def
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// scalar reports whether v is set from a single string.
+func scalar(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// set parses s into v.
+func set(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("expected a duration such as 30s or 2h")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("expected true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected a positive integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected a number")
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// loadFile decodes the YAML file at path into cfg. Keys that do not match a
+// field are an error, so that typos are not silently ignored.
+func loadFile(path string, cfg any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+// flagValue records the flags that are set, to be parsed once the file and
+// environment have been loaded under them.
+type flagValue struct {
+	field   field
+	flagged map[string]string
+}
+
+func (v *flagValue) String() string {
+	return ""
+}
+
+func (v *flagValue) Set(s string) error {
+	v.flagged[v.field.key] = s
+	return nil
+}
+
+// IsBoolFlag lets bool fields be set with a bare -name.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.field.value.Kind() == reflect.Bool
+}
+
+func usage(f field) string {
+	var notes []string
+	if f.env != "" {
+		notes = append(notes, "env "+f.env)
+	}
+	if f.def != nil && *f.def != "" {
+		notes = append(notes, "default "+*f.def)
+	}
+	if len(f.oneof) > 0 {
+		notes = append(notes, "one of "+strings.Join(f.oneof, ", "))
+	}
+	if f.required {
+		notes = append(notes, "required")
+	}
+
+	return strings.Join(notes, ", ")
+}
+
+// from says where a missing value can be set.
+func from(f field) string {
+	if f.env == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" (set %s)", f.env)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unjoin splits an error made by errors.Join, however deeply nested, into the
+// errors it joined.
+func unjoin(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, unjoin(err)...)
+	}
+
+	return errs
+}
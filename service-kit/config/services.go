@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// Redis is where to find Redis. Only the host has to be set.
+type Redis struct {
+	Host     string `yaml:"host" env:"REDIS_HOST"`
+	Port     int    `yaml:"port" env:"REDIS_PORT" default:"6379"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+}
+
+// Addr is the host:port to dial.
+func (r Redis) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+// Require returns an error for each value needed to connect that is missing,
+// for a config that uses Redis at key.
+func (r Redis) Require(key string) error {
+	return Required(key+".host", "REDIS_HOST", r.Host)
+}
+
+// Postgres is where to find Postgres, and which database to use.
+type Postgres struct {
+	Host     string `yaml:"host" env:"POSTGRES_HOST"`
+	Port     int    `yaml:"port" env:"POSTGRES_PORT" default:"5432"`
+	Database string `yaml:"database" env:"POSTGRES_DATABASE"`
+	Username string `yaml:"username" env:"POSTGRES_USERNAME"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+}
+
+// URL is the connection string to pass to pgx.
+func (p Postgres) URL() string {
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(p.Username, p.Password),
+		Host:   net.JoinHostPort(p.Host, strconv.Itoa(p.Port)),
+		Path:   p.Database,
+	}
+
+	return u.String()
+}
+
+// Require returns an error for each value needed to connect that is missing,
+// for a config that uses Postgres at key.
+func (p Postgres) Require(key string) error {
+	return errors.Join(
+		Required(key+".host", "POSTGRES_HOST", p.Host),
+		Required(key+".database", "POSTGRES_DATABASE", p.Database),
+		Required(key+".username", "POSTGRES_USERNAME", p.Username),
+	)
+}
+
+// RabbitMQ is where to find RabbitMQ.
+type RabbitMQ struct {
+	Host     string `yaml:"host" env:"RABBITMQ_HOST"`
+	Port     int    `yaml:"port" env:"RABBITMQ_PORT" default:"5672"`
+	Username string `yaml:"username" env:"RABBITMQ_USERNAME"`
+	Password string `yaml:"password" env:"RABBITMQ_PASSWORD" secret:"true"`
+}
+
+// URL is the AMQP URL to dial.
+func (r RabbitMQ) URL() string {
+	u := &url.URL{
+		Scheme: "amqp",
+		User:   url.UserPassword(r.Username, r.Password),
+		Host:   net.JoinHostPort(r.Host, strconv.Itoa(r.Port)),
+	}
+
+	return u.String()
+}
+
+// Require returns an error for each value needed to connect that is missing,
+// for a config that uses RabbitMQ at key.
+func (r RabbitMQ) Require(key string) error {
+	return errors.Join(
+		Required(key+".host", "RABBITMQ_HOST", r.Host),
+		Required(key+".username", "RABBITMQ_USERNAME", r.Username),
+	)
+}
@@ -0,0 +1,163 @@
+// Package health reports whether the service is alive, and whether the
+// dependencies it needs to serve requests can be reached.
+//
+// Liveness only says the process is running, so an orchestrator restarts it
+// if it stops answering. Readiness runs every check, so traffic is held back
+// while a dependency is down without restarting a service that cannot fix it.
+//
+// It only depends on the standard library, so any service can use it whatever
+// it serves requests with. The checks are served over HTTP, and Watch lets
+// them back another health protocol, such as grpc.health.v1.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check returns an error if a dependency cannot be used.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one Check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check. It is up only if they all are.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Up reports whether every check passed.
+func (r Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+// Checker runs the readiness checks. Checks must all be added before it is
+// first used.
+type Checker struct {
+	timeout time.Duration
+	checks  map[string]Check
+}
+
+// NewChecker returns a Checker that gives each check timeout to pass.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  map[string]Check{},
+	}
+}
+
+// Add adds a check named after the dependency it checks.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Check runs every check at once and reports how each went.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := Result{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Watch runs the checks every interval until ctx is done, passing each report
+// to update.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, update func(Report)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report := c.Check(ctx)
+		if !report.Up() {
+			slog.WarnContext(ctx, "Not ready", slog.Any("checks", report.Checks))
+		}
+		update(report)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ServeHTTP serves the readiness report, with a 503 if any check failed.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Up() {
+		status = http.StatusServiceUnavailable
+	}
+
+	write(w, status, report)
+}
+
+// Live serves the liveness report, which is always up while the process can
+// answer at all.
+func Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, map[string]string{"status": StatusUp})
+	})
+}
+
+func write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Checker(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	checker.Add("redis", func(context.Context) error {
+		return nil
+	})
+	checker.Add("rabbitmq", func(context.Context) error {
+		return errors.New("not connected")
+	})
+	checker.Add("product-service", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Check(context.Background())
+	require.False(t, report.Up())
+	require.Equal(t, StatusUp, report.Checks["redis"].Status)
+	require.Equal(t, Result{Status: StatusDown, LatencyMs: report.Checks["rabbitmq"].LatencyMs, Error: "not connected"}, report.Checks["rabbitmq"])
+	require.Equal(t, StatusDown, report.Checks["product-service"].Status)
+	require.GreaterOrEqual(t, report.Checks["product-service"].LatencyMs, 20.0)
+}
+
+func Test_Checker_ServeHTTP(t *testing.T) {
+	up := true
+
+	checker := NewChecker(time.Second)
+	checker.Add("redis", func(context.Context) error {
+		if !up {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	check := func() (int, Report) {
+		res := httptest.NewRecorder()
+		checker.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var report Report
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &report))
+		require.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+		return res.Code, report
+	}
+
+	code, report := check()
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, report.Up())
+
+	up = false
+	code, report = check()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "connection refused", report.Checks["redis"].Error)
+}
+
+func Test_Live(t *testing.T) {
+	res := httptest.NewRecorder()
+	Live().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.JSONEq(t, `{"status":"up"}`, res.Body.String())
+}
+
+func Test_Checker_Watch(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+
+	checker := NewChecker(time.Second)
+	checker.Add("redis", func(context.Context) error {
+		if !up.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reports := make(chan Report)
+	go checker.Watch(ctx, time.Millisecond, func(report Report) {
+		select {
+		case reports <- report:
+		case <-ctx.Done():
+		}
+	})
+
+	require.True(t, (<-reports).Up())
+
+	up.Store(false)
+	require.Eventually(t, func() bool {
+		return !(<-reports).Up()
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,159 @@
+// Package lifecycle runs a service until it is asked to stop, then shuts it
+// down in order: the server stops taking requests and drains those in flight,
+// background work is cancelled, and dependencies are closed in the reverse of
+// the order they were opened, all within one deadline.
+//
+// It only depends on the standard library, so any service can use it whatever
+// it serves requests with.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Manager tracks what a service has to stop and close when it shuts down.
+type Manager struct {
+	timeout time.Duration
+
+	// ctx is given to background work and cancelled once the server has
+	// stopped.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	closers []closer
+}
+
+type closer struct {
+	name  string
+	close func(context.Context) error
+}
+
+// New returns a Manager that allows timeout for the whole shutdown.
+func New(timeout time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Go runs fn in the background. Its context is cancelled once the server has
+// stopped, and shutdown waits for fn to return before closing anything it may
+// be using.
+func (m *Manager) Go(fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn(m.ctx)
+	}()
+}
+
+// OnShutdown registers close to be called at shutdown. Everything registered
+// is closed in the reverse of the order it was registered in, so register a
+// dependency as soon as it is opened and it will outlive everything that uses
+// it. Every close is called even if an earlier one fails.
+func (m *Manager) OnShutdown(name string, close func(context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closers = append(m.closers, closer{name: name, close: close})
+}
+
+// Close adapts a Close method that takes no context for OnShutdown.
+func Close(close func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
+}
+
+// Run calls serve and blocks until it returns or the process gets SIGINT or
+// SIGTERM. On a signal stop is called to stop the server gracefully; serve
+// returning by itself is a failure, so its error is returned. Either way the
+// rest of the service is then shut down, and any errors from that are
+// returned too.
+//
+// A second signal while shutting down kills the process.
+func (m *Manager) Run(serve func() error, stop func(context.Context) error) error {
+	signals, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	var err error
+	select {
+	case <-signals.Done():
+		slog.Info("Shutting down", slog.Duration("timeout", m.timeout))
+	case err = <-served:
+		if err == nil {
+			err = errors.New("server stopped unexpectedly")
+		}
+		slog.Error("Server failed, shutting down", slog.Any("error", err))
+		stop = nil
+	}
+
+	// restore the default behaviour, so another signal kills the process
+	cancel()
+
+	ctx, done := context.WithTimeout(context.Background(), m.timeout)
+	defer done()
+
+	return errors.Join(err, m.shutdown(ctx, stop))
+}
+
+func (m *Manager) shutdown(ctx context.Context, stop func(context.Context) error) error {
+	var errs []error
+
+	if stop != nil {
+		if err := stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping server: %w", err))
+		}
+	}
+
+	m.cancel()
+	if err := wait(ctx, &m.workers); err != nil {
+		errs = append(errs, fmt.Errorf("waiting for background work: %w", err))
+	}
+
+	m.mu.Lock()
+	closers := m.closers
+	m.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("closing %s: %w", closers[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// wait waits for wg, or returns ctx's error if it is done first.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
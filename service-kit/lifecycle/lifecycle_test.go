@@ -0,0 +1,96 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Manager_Run(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	m := New(time.Second)
+	m.OnShutdown("telemetry", func(context.Context) error {
+		record("telemetry")
+		return nil
+	})
+	m.OnShutdown("redis", func(context.Context) error {
+		record("redis")
+		return errors.New("already closed")
+	})
+	m.OnShutdown("rabbitmq", func(context.Context) error {
+		record("rabbitmq")
+		return nil
+	})
+	m.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		record("worker")
+	})
+
+	stopped := make(chan struct{})
+	err := m.Run(func() error {
+		// the signal handler is installed before serve is called
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+		<-stopped
+		return nil
+	}, func(context.Context) error {
+		record("server")
+		close(stopped)
+		return nil
+	})
+
+	require.ErrorContains(t, err, "closing redis: already closed")
+	require.Equal(t, []string{"server", "worker", "rabbitmq", "redis", "telemetry"}, order)
+}
+
+func Test_Manager_Run_ServeFails(t *testing.T) {
+	closed := false
+
+	m := New(time.Second)
+	m.OnShutdown("redis", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Run(func() error {
+		return errors.New("address already in use")
+	}, func(context.Context) error {
+		t.Fatal("the server is not running, so is not stopped")
+		return nil
+	})
+
+	require.ErrorContains(t, err, "address already in use")
+	require.True(t, closed)
+}
+
+func Test_Manager_Run_Deadline(t *testing.T) {
+	m := New(10 * time.Millisecond)
+	m.Go(func(context.Context) {
+		// ignores cancellation
+		time.Sleep(time.Second)
+	})
+
+	var deadline error
+	m.OnShutdown("rabbitmq", func(ctx context.Context) error {
+		deadline = ctx.Err()
+		return nil
+	})
+
+	err := m.Run(func() error {
+		return nil
+	}, nil)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, deadline, context.DeadlineExceeded)
+}
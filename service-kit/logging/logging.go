@@ -0,0 +1,211 @@
+// Package logging sets services up to write JSON logs that promtail can ship
+// to Loki as they are: one record per line, with flat snake_case keys for the
+// IDs a line can be searched by.
+//
+// Records logged with a request's context carry its request ID, the trace it
+// was sent as part of and any IDs added with WithAttrs, such as the basket it
+// was for, without each call site having to add them.
+//
+// It only depends on the standard library, so any service can use it
+// whatever it serves requests with.
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Keys of the attributes added to records. ParentSpanIDKey is the caller's
+// span, from its traceparent header; SpanIDKey is for services that trace
+// their own spans.
+const (
+	TraceIDKey      = "trace_id"
+	SpanIDKey       = "span_id"
+	ParentSpanIDKey = "parent_span_id"
+	RequestIDKey    = "request_id"
+	BasketIDKey     = "basket_id"
+
+	WorkflowIDKey = "workflow_id"
+	RunIDKey      = "run_id"
+)
+
+const (
+	// RequestIDHeader carries a request's ID. An ID sent by the caller is
+	// kept, so a request can be followed across services.
+	RequestIDHeader = "X-Request-ID"
+
+	// TraceparentHeader is the W3C trace context header a caller sends its
+	// trace in.
+	TraceparentHeader = "traceparent"
+)
+
+// New returns a logger writing JSON records, one per line, to w, with the
+// level as "level" and the message as "msg".
+func New(w io.Writer) *slog.Logger {
+	return slog.New(NewHandler(slog.NewJSONHandler(w, nil)))
+}
+
+// NewHandler wraps h so that records logged with a context include the
+// attributes put on it with WithAttrs. It is for services that add more to
+// every record, such as the IDs of their own spans.
+func NewHandler(h slog.Handler) slog.Handler {
+	return handler{Handler: h}
+}
+
+// handler adds the attributes put on a context with WithAttrs to every record
+// logged with it.
+type handler struct {
+	slog.Handler
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{Handler: h.Handler.WithGroup(name)}
+}
+
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx whose records include attrs. An attribute
+// replaces one with the same key already on ctx.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, attr := range existing {
+		if !hasKey(attrs, attr.Key) {
+			merged = append(merged, attr)
+		}
+	}
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Request returns the attributes to log a request with, from the values of
+// its X-Request-ID and traceparent headers, and the request ID to send back.
+// A request without a usable ID is given a new one.
+func Request(requestID, traceparent string) (string, []slog.Attr) {
+	if requestID == "" || len(requestID) > 128 {
+		requestID = newID()
+	}
+
+	attrs := []slog.Attr{slog.String(RequestIDKey, requestID)}
+
+	// version-traceid-parentid-flags, the parent being the caller's span
+	parts := strings.Split(traceparent, "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+		attrs = append(attrs, slog.String(TraceIDKey, parts[1]), slog.String(ParentSpanIDKey, parts[2]))
+	}
+
+	return requestID, attrs
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder remembers the status a handler responded with.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush and Hijack keep streamed responses and websocket subscriptions
+// working through the recorder.
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware adds the request's ID and trace to the records logged for it,
+// sends the ID back in the X-Request-ID header and logs one line once the
+// request has completed. The ID is also set on the request, for next to pass
+// on to anything it calls.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id, attrs := Request(r.Header.Get(RequestIDHeader), r.Header.Get(TraceparentHeader))
+		r.Header.Set(RequestIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+
+		r = r.WithContext(WithAttrs(r.Context(), attrs...))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		slog.InfoContext(r.Context(), "request completed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// Error logs err against the request and responds with status and
+// {"message": message}, rather than taking the whole service down with it. Statuses below 500
+// are the caller's fault, so are logged as warnings.
+func Error(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
+	level := slog.LevelError
+	if status < http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	slog.Log(r.Context(), level, message, slog.Int("status", status), slog.Any("error", err))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// Fatal logs msg and err, then exits. It is only for startup; requests report
+// their errors to the caller.
+func Fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
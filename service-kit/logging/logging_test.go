@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Middleware(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithAttrs(r.Context(), slog.String(BasketIDKey, "basket-1"))
+		slog.ErrorContext(ctx, "could not load basket")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/graphql", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res := httptest.NewRecorder()
+
+	Middleware(next).ServeHTTP(res, req)
+	require.Equal(t, "req-1", res.Header().Get(RequestIDHeader))
+
+	decoder := json.NewDecoder(&buf)
+
+	var record map[string]any
+	require.NoError(t, decoder.Decode(&record))
+	require.Equal(t, "could not load basket", record["msg"])
+	require.Equal(t, "req-1", record[RequestIDKey])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record[TraceIDKey])
+	require.Equal(t, "00f067aa0ba902b7", record[ParentSpanIDKey], "the caller's span is the parent")
+	require.NotContains(t, record, SpanIDKey)
+	require.Equal(t, "basket-1", record[BasketIDKey])
+
+	require.NoError(t, decoder.Decode(&record))
+	require.Equal(t, "request completed", record["msg"])
+	require.Equal(t, float64(http.StatusInternalServerError), record["status"])
+}
+
+func Test_Request_GeneratesID(t *testing.T) {
+	id, attrs := Request("", "not a traceparent")
+
+	require.Len(t, id, 32)
+	require.Equal(t, []slog.Attr{slog.String(RequestIDKey, id)}, attrs)
+}
+
+func Test_WithAttrs_Replaces(t *testing.T) {
+	ctx := WithAttrs(context.Background(), slog.String(BasketIDKey, "a"), slog.String(RequestIDKey, "r"))
+	ctx = WithAttrs(ctx, slog.String(BasketIDKey, "b"))
+
+	require.Equal(t, []slog.Attr{slog.String(RequestIDKey, "r"), slog.String(BasketIDKey, "b")}, ctx.Value(attrsKey{}))
+}
+
+func Test_Error(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := httptest.NewRequest("POST", "/start", nil)
+	req = req.WithContext(WithAttrs(req.Context(), slog.String(WorkflowIDKey, "onboarding")))
+	res := httptest.NewRecorder()
+
+	Error(res, req, http.StatusBadRequest, "Unable to parse body", errors.New("unexpected EOF"))
+
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"message":"Unable to parse body"}`, res.Body.String())
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "WARN", record["level"])
+	require.Equal(t, "Unable to parse body", record["msg"])
+	require.Equal(t, "unexpected EOF", record["error"])
+	require.Equal(t, "onboarding", record[WorkflowIDKey])
+}
@@ -2,7 +2,10 @@ module github.com/Jamess-Lucass/workflow-engines/conductor/onboarding
 
 go 1.22.0
 
-require github.com/conductor-sdk/conductor-go v1.3.8
+require (
+	github.com/conductor-sdk/conductor-go v1.3.8
+	service-kit v0.0.0
+)
 
 require (
 	github.com/antihax/optional v1.0.0 // indirect
@@ -21,3 +24,5 @@ require (
 	golang.org/x/sys v0.18.0 // indirect
 	google.golang.org/protobuf v1.33.0 // indirect
 )
+
+replace service-kit => ../../../service-kit
@@ -1,14 +1,15 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
+	"service-kit/logging"
+
 	"github.com/conductor-sdk/conductor-go/sdk/client"
 	"github.com/conductor-sdk/conductor-go/sdk/model"
 	"github.com/conductor-sdk/conductor-go/sdk/settings"
@@ -40,6 +41,8 @@ type OnboardingApprovalRequest struct {
 }
 
 func main() {
+	slog.SetDefault(logging.New(os.Stdout))
+
 	c := client.NewAPIClient(
 		nil,
 		settings.NewHttpSettings(
@@ -54,12 +57,16 @@ func main() {
 
 	s := NewServer(workflowExecutor)
 
-	http.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
 
-	fmt.Println("Starting web server on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	slog.Info("Starting web server on http://localhost:8080")
+	if err := http.ListenAndServe(":8080", logging.Middleware(mux)); err != nil {
+		slog.Error("web server failed", slog.Any("error", err))
+		os.Exit(1)
+	}
 }
 
 func (s *Server) ApproveOnboarding(w http.ResponseWriter, r *http.Request) {
@@ -71,12 +78,16 @@ func (s *Server) ApproveOnboarding(w http.ResponseWriter, r *http.Request) {
 
 	s.client.
 
-	err := s.client.SignalWorkflow(context.Background(), "onboarding-workflow", runId, "onboarding-approval", signal)
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, runId))
+	r = r.WithContext(ctx)
+
+	err := s.client.SignalWorkflow(ctx, "onboarding-workflow", runId, "onboarding-approval", signal)
 	if err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding approved")
 	json.NewEncoder(w).Encode("Approved")
 }
 
@@ -87,23 +98,27 @@ func (s *Server) DenyOnboarding(w http.ResponseWriter, r *http.Request) {
 		Approved: false,
 	}
 
-	err := s.client.SignalWorkflow(context.Background(), "onboarding-workflow", runId, "onboarding-approval", signal)
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, runId))
+	r = r.WithContext(ctx)
+
+	err := s.client.SignalWorkflow(ctx, "onboarding-workflow", runId, "onboarding-approval", signal)
 	if err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding denied")
 	json.NewEncoder(w).Encode("Denied")
 }
 
 func (s *Server) handleCreateOnboarding(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	log.Printf("onboarding request")
+	slog.InfoContext(r.Context(), "onboarding request")
 
 	var request OnboardingRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		json.NewEncoder(w).Encode("Unable to parse body")
+		logging.Error(w, r, http.StatusBadRequest, "Unable to parse body", err)
 		return
 	}
 
@@ -119,9 +134,12 @@ func (s *Server) handleCreateOnboarding(w http.ResponseWriter, r *http.Request)
 		"",
 	)
 	if err != nil {
-		log.Fatalln("unable to complete Workflow", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Unable to start onboarding", err)
+		return
 	}
 
+	slog.InfoContext(r.Context(), "onboarding started", slog.String(logging.WorkflowIDKey, workflowId.WorkflowId))
+
 	// Get the results
 	// var fullname string
 	// err = we.Get(context.Background(), &fullname)
@@ -171,7 +189,10 @@ func CreateOnboardingWorkflow(workflowExecutor *executor.WorkflowExecutor) *work
 // }
 
 func CreateUser(task *model.Task) (interface{}, error) {
-	log.Printf("DATA: %v", task.TaskDefinition.InputTemplate)
+	slog.Info("create user task",
+		slog.String(logging.WorkflowIDKey, task.WorkflowInstanceId),
+		slog.Any("input", task.TaskDefinition.InputTemplate),
+	)
 	// fullname := fmt.Sprintf("%s %s", request.Firstname, request.Lastname)
 
 	// return fullname, nil
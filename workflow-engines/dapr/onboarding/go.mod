@@ -2,7 +2,10 @@ module github.com/Jamess-Lucass/workflow-engines/dapr/onboarding
 
 go 1.22.0
 
-require github.com/conductor-sdk/conductor-go v1.3.8
+require (
+	github.com/conductor-sdk/conductor-go v1.3.8
+	service-kit v0.0.0
+)
 
 require (
 	github.com/antihax/optional v1.0.0 // indirect
@@ -37,3 +40,5 @@ require (
 	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace service-kit => ../../../service-kit
@@ -1,15 +1,17 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
+	"os"
 	"time"
 
+	"service-kit/logging"
+
 	"github.com/dapr/go-sdk/client"
 	"github.com/dapr/go-sdk/workflow"
 )
@@ -35,91 +37,96 @@ type OnboardingApprovalRequest struct {
 }
 
 func main() {
-	w, err := workflow.NewWorker()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if err := w.RegisterWorkflow(OnboardingWorkflow); err != nil {
-		log.Fatal(err)
-	}
+	slog.SetDefault(logging.New(os.Stdout))
 
-	if err := w.RegisterActivity(CreateUser); err != nil {
-		log.Fatal(err)
-	}
+	w, err := workflow.NewWorker()
+	failOnError(err, "failed to create workflow worker")
 
-	if err := w.Start(); err != nil {
-		log.Fatal(err)
-	}
+	failOnError(w.RegisterWorkflow(OnboardingWorkflow), "failed to register workflow")
+	failOnError(w.RegisterActivity(CreateUser), "failed to register activity")
+	failOnError(w.Start(), "failed to start workflow worker")
 
 	c, err := client.NewClient()
-	if err != nil {
-		log.Fatalf("failed to intialise client: %v", err)
-	}
+	failOnError(err, "failed to intialise client")
 
 	s := NewServer(c)
 
-	http.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
+
+	slog.Info("Starting web server on http://localhost:8080")
+	failOnError(http.ListenAndServe(":8080", logging.Middleware(mux)), "web server failed")
+}
 
-	fmt.Println("Starting web server on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal(err)
+// failOnError exits if the service could not start. It is only for startup;
+// requests report their errors to the caller.
+func failOnError(err error, msg string) {
+	if err != nil {
+		slog.Error(msg, slog.Any("error", err))
+		os.Exit(1)
 	}
 }
 
 func (s *Server) ApproveOnboarding(w http.ResponseWriter, r *http.Request) {
 	runId := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, runId))
+	r = r.WithContext(ctx)
 
 	req := OnboardingApprovalRequest{
 		Approved: true,
 	}
 
-	if err := s.client.RaiseEventWorkflowBeta1(context.Background(), &client.RaiseEventWorkflowRequest{
+	if err := s.client.RaiseEventWorkflowBeta1(ctx, &client.RaiseEventWorkflowRequest{
 		InstanceID: runId,
 		EventName:  "onboarding-approval",
 		EventData:  req,
 	}); err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding approved")
 	json.NewEncoder(w).Encode("Approved")
 }
 
 func (s *Server) DenyOnboarding(w http.ResponseWriter, r *http.Request) {
 	runId := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, runId))
+	r = r.WithContext(ctx)
 
 	req := OnboardingApprovalRequest{
 		Approved: false,
 	}
 
-	if err := s.client.RaiseEventWorkflowBeta1(context.Background(), &client.RaiseEventWorkflowRequest{
+	if err := s.client.RaiseEventWorkflowBeta1(ctx, &client.RaiseEventWorkflowRequest{
 		InstanceID: runId,
 		EventName:  "onboarding-approval",
 		EventData:  req,
 	}); err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding denied")
 	json.NewEncoder(w).Encode("Denied")
 }
 
 func (s *Server) handleCreateOnboarding(w http.ResponseWriter, r *http.Request) {
-	log.Printf("onboarding request")
+	slog.InfoContext(r.Context(), "onboarding request")
 	w.Header().Set("Content-Type", "application/json")
 
 	var request OnboardingRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		json.NewEncoder(w).Encode("Unable to parse body")
+		logging.Error(w, r, http.StatusBadRequest, "Unable to parse body", err)
 		return
 	}
 
 	wfClient, err := workflow.NewClient(workflow.WithDaprClient(s.client))
 	if err != nil {
-		log.Fatalf("failed to initialise workflow client: %v", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Unable to start onboarding", err)
+		return
 	}
 
 	options := &client.StartWorkflowRequest{
@@ -129,20 +136,27 @@ func (s *Server) handleCreateOnboarding(w http.ResponseWriter, r *http.Request)
 		SendRawInput: false,
 	}
 
-	we, err := s.client.StartWorkflowBeta1(context.Background(), options)
+	we, err := s.client.StartWorkflowBeta1(r.Context(), options)
 	if err != nil {
-		log.Fatalln("unable to start Workflow", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Unable to start onboarding", err)
+		return
 	}
 
-	metadata, err := wfClient.WaitForWorkflowCompletion(r.Context(), we.InstanceID)
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, we.InstanceID))
+	r = r.WithContext(ctx)
+	slog.InfoContext(ctx, "onboarding started")
+
+	metadata, err := wfClient.WaitForWorkflowCompletion(ctx, we.InstanceID)
 	if err != nil {
-		log.Fatalln("unable to wait for workflow completion", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Onboarding did not complete", err)
+		return
 	}
 
 	// Get the results
 	var fullname string
 	if err := json.Unmarshal([]byte(metadata.SerializedOutput), &fullname); err != nil {
-		log.Fatalln("unable to unmarshal state data", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Onboarding did not complete", err)
+		return
 	}
 
 	json.NewEncoder(w).Encode(fullname)
@@ -2,7 +2,10 @@ module github.com/Jamess-Lucass/workflow-engines/temporal/greeting
 
 go 1.22.0
 
-require go.temporal.io/sdk v1.26.0
+require (
+	go.temporal.io/sdk v1.26.0
+	service-kit v0.0.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -30,3 +33,5 @@ require (
 	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace service-kit => ../../../service-kit
@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
+	"service-kit/logging"
+
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 )
@@ -26,9 +30,12 @@ func NewServer(client client.Client) *Server {
 }
 
 func main() {
-	c, err := client.Dial(client.Options{})
+	slog.SetDefault(logging.New(os.Stdout))
+
+	c, err := client.Dial(client.Options{Logger: log.NewStructuredLogger(slog.Default())})
 	if err != nil {
-		log.Fatalln("unable to create Temporal client", err)
+		slog.Error("unable to create Temporal client", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer c.Close()
 
@@ -39,24 +46,29 @@ func main() {
 
 	s := NewServer(c)
 
-	http.HandleFunc("GET /greeting", s.handleGreeting)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /greeting", s.handleGreeting)
 
 	go func() {
-		fmt.Println("Starting web server on http://localhost:8080")
-		log.Fatal(http.ListenAndServe(":8080", nil))
+		slog.Info("Starting web server on http://localhost:8080")
+		if err := http.ListenAndServe(":8080", logging.Middleware(mux)); err != nil {
+			slog.Error("web server failed", slog.Any("error", err))
+			os.Exit(1)
+		}
 	}()
 
-	fmt.Println("Starting temporal worker")
+	slog.Info("Starting temporal worker")
 	err = w.Run(worker.InterruptCh())
 	if err != nil {
-		log.Fatalln("unable to start Worker", err)
+		slog.Error("unable to start Worker", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
 
 func (s *Server) handleGreeting(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	log.Printf("making request /api/v1/test")
+	slog.InfoContext(r.Context(), "greeting request")
 
 	options := client.StartWorkflowOptions{
 		ID:        "greeting-workflow",
@@ -64,16 +76,21 @@ func (s *Server) handleGreeting(w http.ResponseWriter, r *http.Request) {
 	}
 
 	name := "World"
-	we, err := s.client.ExecuteWorkflow(context.Background(), options, GreetingWorkflow, name)
+	we, err := s.client.ExecuteWorkflow(r.Context(), options, GreetingWorkflow, name)
 	if err != nil {
-		log.Fatalln("unable to complete Workflow", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Unable to start greeting", err)
+		return
 	}
 
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, we.GetID()), slog.String(logging.RunIDKey, we.GetRunID()))
+	r = r.WithContext(ctx)
+
 	// Get the results
 	var greeting string
-	err = we.Get(context.Background(), &greeting)
+	err = we.Get(ctx, &greeting)
 	if err != nil {
-		log.Fatalln("unable to get Workflow result", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Greeting did not complete", err)
+		return
 	}
 
 	json.NewEncoder(w).Encode("test")
@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
 
+	"service-kit/logging"
+
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 )
 
 const OnboardingTaskQueue = "ONBOARDING_TASK_QUEUE"
 
+const OnboardingWorkflowID = "onboarding-workflow"
+
 type Server struct {
 	client client.Client
 }
@@ -37,9 +43,12 @@ type OnboardingApprovalRequest struct {
 }
 
 func main() {
-	c, err := client.Dial(client.Options{})
+	slog.SetDefault(logging.New(os.Stdout))
+
+	c, err := client.Dial(client.Options{Logger: log.NewStructuredLogger(slog.Default())})
 	if err != nil {
-		log.Fatalln("unable to create Temporal client", err)
+		slog.Error("unable to create Temporal client", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer c.Close()
 
@@ -50,81 +59,96 @@ func main() {
 
 	s := NewServer(c)
 
-	http.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
-	http.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /onboarding", s.handleCreateOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/approve", s.ApproveOnboarding)
+	mux.HandleFunc("POST /onboardings/{id}/deny", s.DenyOnboarding)
 
 	go func() {
-		fmt.Println("Starting web server on http://localhost:8080")
-		if err := http.ListenAndServe(":8080", nil); err != nil {
-			log.Fatal(err)
+		slog.Info("Starting web server on http://localhost:8080")
+		if err := http.ListenAndServe(":8080", logging.Middleware(mux)); err != nil {
+			slog.Error("web server failed", slog.Any("error", err))
+			os.Exit(1)
 		}
 	}()
 
-	fmt.Println("Starting temporal worker")
+	slog.Info("Starting temporal worker")
 	err = w.Run(worker.InterruptCh())
 	if err != nil {
-		log.Fatalln("unable to start Worker", err)
+		slog.Error("unable to start Worker", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
 
 func (s *Server) ApproveOnboarding(w http.ResponseWriter, r *http.Request) {
 	runId := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, OnboardingWorkflowID), slog.String(logging.RunIDKey, runId))
+	r = r.WithContext(ctx)
 
 	req := OnboardingApprovalRequest{
 		Approved: true,
 	}
 
-	err := s.client.SignalWorkflow(context.Background(), "onboarding-workflow", runId, "onboarding-approval", req)
+	err := s.client.SignalWorkflow(ctx, OnboardingWorkflowID, runId, "onboarding-approval", req)
 	if err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding approved")
 	json.NewEncoder(w).Encode("Approved")
 }
 
 func (s *Server) DenyOnboarding(w http.ResponseWriter, r *http.Request) {
 	runId := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, OnboardingWorkflowID), slog.String(logging.RunIDKey, runId))
+	r = r.WithContext(ctx)
 
 	req := OnboardingApprovalRequest{
 		Approved: false,
 	}
 
-	err := s.client.SignalWorkflow(context.Background(), "onboarding-workflow", runId, "onboarding-approval", req)
+	err := s.client.SignalWorkflow(ctx, OnboardingWorkflowID, runId, "onboarding-approval", req)
 	if err != nil {
-		json.NewEncoder(w).Encode(err.Error())
+		logging.Error(w, r, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
+	slog.InfoContext(ctx, "onboarding denied")
 	json.NewEncoder(w).Encode("Denied")
 }
 
 func (s *Server) handleCreateOnboarding(w http.ResponseWriter, r *http.Request) {
-	log.Printf("onboarding request")
+	slog.InfoContext(r.Context(), "onboarding request")
 	w.Header().Set("Content-Type", "application/json")
 
 	var request OnboardingRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		json.NewEncoder(w).Encode("Unable to parse body")
+		logging.Error(w, r, http.StatusBadRequest, "Unable to parse body", err)
 		return
 	}
 
 	options := client.StartWorkflowOptions{
-		ID:        "onboarding-workflow",
+		ID:        OnboardingWorkflowID,
 		TaskQueue: OnboardingTaskQueue,
 	}
 
-	we, err := s.client.ExecuteWorkflow(context.Background(), options, OnboardingWorkflow, request)
+	we, err := s.client.ExecuteWorkflow(r.Context(), options, OnboardingWorkflow, request)
 	if err != nil {
-		log.Fatalln("unable to complete Workflow", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Unable to start onboarding", err)
+		return
 	}
 
+	ctx := logging.WithAttrs(r.Context(), slog.String(logging.WorkflowIDKey, we.GetID()), slog.String(logging.RunIDKey, we.GetRunID()))
+	r = r.WithContext(ctx)
+	slog.InfoContext(ctx, "onboarding started")
+
 	// Get the results
 	var fullname string
-	err = we.Get(context.Background(), &fullname)
+	err = we.Get(ctx, &fullname)
 	if err != nil {
-		log.Fatalln("unable to get Workflow result", err)
+		logging.Error(w, r, http.StatusInternalServerError, "Onboarding did not complete", err)
+		return
 	}
 
 	json.NewEncoder(w).Encode(fullname)